@@ -1,131 +1,1290 @@
 package fuzzypatch
 
 import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
-	"sort"
+	"iter"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 
-	"github.com/agnivade/levenshtein"
+	"golang.org/x/text/unicode/norm"
 )
 
 // Diff represents a text replacement operation with search and replace strings
 // that should be applied at a specific line position in a document.
 type Diff struct {
-	Line    int     // 1-based line number where the search should start
-	Search  string  // Text to find in the document
-	Replace string  // Text to replace the found section with
+	Line    int    `json:"line,omitempty" yaml:"line,omitempty"`       // 1-based line number where the search should start, 0 if unknown
+	Search  string `json:"search" yaml:"search"`                       // Text to find in the document
+	Replace string `json:"replace" yaml:"replace"`                     // Text to replace the found section with
+	Comment string `json:"comment,omitempty" yaml:"comment,omitempty"` // Description of the change, if any
+
+	// LineEnd is the last line of an inclusive [Line, LineEnd] range that the
+	// match must fall within. It's 0 when the match isn't constrained.
+	LineEnd int `json:"line_end,omitempty" yaml:"line_end,omitempty"`
+
+	// Column is the 1-based byte offset within the hinted line where the
+	// match should start, or 0 if unknown. It decides between matches that
+	// start on the same line, such as repeated text in a long line.
+	Column int `json:"column,omitempty" yaml:"column,omitempty"`
+
+	// Fingerprint is the Fingerprint of a line of context above the diff,
+	// such as its ContextFingerprint. When it's set and the line is found,
+	// the search starts below it instead of at Line, which keeps working
+	// after the line numbers have drifted far.
+	Fingerprint string `json:"fingerprint,omitempty" yaml:"fingerprint,omitempty"`
+
+	// Reject lists text that mustn't appear on the matched lines or within
+	// RejectRadius lines of them, such as a guard showing that the change
+	// was already made. Candidates with a line containing any of them,
+	// ignoring surrounding whitespace, don't match.
+	Reject       []string `json:"reject,omitempty" yaml:"reject,omitempty"`
+	RejectRadius int      `json:"reject_radius,omitempty" yaml:"reject_radius,omitempty"`
+
+	// ContextBefore and ContextAfter are the number of leading and trailing
+	// lines of Search that only place the match, like the context lines of a
+	// unified diff. They're matched, but left out of the replaced range, so
+	// Replace doesn't restate them. They don't apply to Regex diffs, or to
+	// searches within a line.
+	ContextBefore int `json:"context_before,omitempty" yaml:"context_before,omitempty"`
+	ContextAfter  int `json:"context_after,omitempty" yaml:"context_after,omitempty"`
+
+	// Occurrence selects the k'th non-overlapping match in document order
+	// instead of the match nearest to Line. It's 1-based, and 0 disables it.
+	Occurrence int `json:"occurrence,omitempty" yaml:"occurrence,omitempty"`
+
+	// Threshold overrides the similarity threshold passed to Search,
+	// SearchMatch, and SearchAll for this diff. It's 0 when the caller's
+	// threshold should be used.
+	Threshold float64 `json:"threshold,omitempty" yaml:"threshold,omitempty"`
+
+	// Regex makes Search a regular expression in Go's RE2 syntax instead
+	// of text to match fuzzily. The edit covers exactly what it matched, and
+	// Replace can refer to submatches with $1 or ${name}. Use $$ for a
+	// literal dollar sign.
+	Regex bool `json:"regex,omitempty" yaml:"regex,omitempty"`
+
+	// Span is the location of the diff in the patch it was parsed from.
+	Span Span `json:"-" yaml:"-"`
+}
+
+// Span is a range of lines in a patch.
+type Span struct {
+	Start int // 1-based first line, 0 if unknown
+	End   int // 1-based last line (inclusive), 0 if unknown
 }
 
+// EndOfFile is a Diff.Line value that refers to the end of the document.
+// Insertions with this hint are appended to the document, and searches
+// start from the last line.
+const EndOfFile = -1
+
+// bom is the UTF-8 byte order mark.
+const bom = "\uFEFF"
+
 // Edit represents a specific text edit operation with byte offsets
 // that can be applied to a document.
 type Edit struct {
-	Start int    // Byte offset where the edit starts (inclusive)
-	End   int    // Byte offset where the edit ends (exclusive)
-	Text  string // New text to replace the section between Start and End
+	Start int    `json:"start"` // Byte offset where the edit starts (inclusive)
+	End   int    `json:"end"`   // Byte offset where the edit ends (exclusive)
+	Text  string `json:"text"`  // New text to replace the section between Start and End
+}
+
+// DefaultThreshold is the similarity threshold used by Search when the
+// WithThreshold option isn't given.
+const DefaultThreshold = 0.9
+
+// SearchOption configures how Search locates text.
+type SearchOption func(*searchConfig)
+
+type searchConfig struct {
+	threshold  float64
+	maxRadius  int
+	radiusFrac float64
+	foldCase   bool
+	whitespace WhitespaceMode
+	tieBreak   TieBreak
+	bestMatch  bool
+	indent     bool
+	nfc        bool
+	ascii      bool
+	ambiguity  float64 // negative when ambiguity isn't checked
+	metric     Metric
+	anchors    int
+	heights    int
+	policy     ThresholdPolicy
+	lineRange  [2]int // 1-based and inclusive, unset when the end is 0
+	byteRange  [2]int // [start, end), unset when the end is 0
+	exclude    []Edit
+	trace      *Trace
+	closest    int // how many candidates a NoMatchError lists
+	templates  bool
+	keepIndent bool
+	comments   CommentSyntax
+	locator    Locator
+	subLine    bool
+	tabWidth   int
+	strictHint bool
+	ellipsis   bool
+	split      bufio.SplitFunc
+}
+
+func newSearchConfig(opts []SearchOption) *searchConfig {
+	cfg := &searchConfig{threshold: DefaultThreshold, ambiguity: -1, metric: Levenshtein}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithThreshold sets the minimum similarity, between 0 and 1, that a
+// candidate must have to match.
+func WithThreshold(threshold float64) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.threshold = threshold
+	}
+}
+
+// ThresholdPolicy returns the similarity threshold to use for a search text.
+type ThresholdPolicy func(search string) float64
+
+// WithThresholdPolicy picks the threshold for each diff with p instead of
+// using a fixed one. It takes precedence over the WithThreshold option.
+func WithThresholdPolicy(p ThresholdPolicy) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.policy = p
+	}
+}
+
+// LengthAdaptive returns a policy that allows a fixed share of the search
+// text to differ once it's at least n runes long. Shorter text is allowed
+// proportionally fewer edits, so the threshold rises linearly towards 1 as
+// the text gets shorter and one-liners need to match almost exactly.
+func LengthAdaptive(threshold float64, n int) ThresholdPolicy {
+	return func(search string) float64 {
+		length := utf8.RuneCountInString(search)
+		if length >= n {
+			return threshold
+		}
+		return 1 - (1-threshold)*float64(length)/float64(n)
+	}
+}
+
+// WithMaxRadius limits how many lines away from the line hint a match can
+// start. It has no effect on diffs without a line hint, and 0 means there's
+// no limit.
+func WithMaxRadius(n int) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.maxRadius = n
+	}
+}
+
+// WithMaxRadiusFraction limits how far from the line hint a match can start
+// to a fraction of the document's lines, such as 0.1 for a tenth of the file.
+// When WithMaxRadius is also given, the smaller limit applies.
+func WithMaxRadiusFraction(f float64) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.radiusFrac = f
+	}
+}
+
+// WithLineRange restricts matches to lines start through end, which are
+// 1-based and inclusive, such as the span of a single function. It's
+// combined with the diff's own line range when it has one.
+func WithLineRange(start, end int) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.lineRange = [2]int{start, end}
+	}
+}
+
+// WithByteRange restricts matches to the lines that fall entirely within
+// the bytes [start, end) of the source.
+func WithByteRange(start, end int) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.byteRange = [2]int{start, end}
+	}
+}
+
+// WithExclude prevents matches from overlapping the given edits, such as
+// the ones found for earlier diffs in the same patch, so later diffs can't
+// land on text that's already being replaced.
+func WithExclude(edits ...Edit) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.exclude = append(cfg.exclude, edits...)
+	}
+}
+
+// WithStrictHint only tries a match at the hinted line, and not anywhere
+// near it, so diffs without a line hint never match. Combine it with
+// WithThreshold(1) to also require the text to match exactly.
+func WithStrictHint() SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.strictHint = true
+	}
+}
+
+// WithEllipsis makes a "..." line in the middle of the search text match any
+// number of lines. The lines it matched take the place of the corresponding
+// "..." line in the replace text, and when the replace text has none, they're
+// replaced along with the rest of the match.
+func WithEllipsis() SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.ellipsis = true
+	}
+}
+
+// WithCaseFolding makes the comparison case-insensitive.
+func WithCaseFolding() SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.foldCase = true
+	}
+}
+
+// WhitespaceMode controls how whitespace is compared.
+type WhitespaceMode int
+
+const (
+	WhitespaceExact       WhitespaceMode = iota // Whitespace must match
+	WhitespaceTrim                              // Leading and trailing whitespace on each line is ignored
+	WhitespaceCollapse                          // Like WhitespaceTrim, and runs of whitespace compare equal
+	WhitespaceInsensitive                       // Trailing whitespace is ignored, and runs of spaces and tabs compare equal
+)
+
+// WithWhitespace sets how whitespace is compared.
+func WithWhitespace(mode WhitespaceMode) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.whitespace = mode
+	}
+}
+
+// TieBreak decides which of two candidates at the same distance from the
+// line hint is preferred.
+type TieBreak int
+
+const (
+	PreferAbove TieBreak = iota // Prefer the candidate above the hint
+	PreferBelow                 // Prefer the candidate below the hint
+)
+
+// WithTieBreak sets which candidate wins when matches are found the same
+// distance above and below the line hint.
+func WithTieBreak(tb TieBreak) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.tieBreak = tb
+	}
+}
+
+// WithBestMatch makes Search consider every candidate within the maximum
+// radius and pick the one with the highest score, instead of the first one
+// that satisfies the threshold. Ties go to the candidate nearest the hint.
+func WithBestMatch() SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.bestMatch = true
+	}
+}
+
+// WithAmbiguityCheck makes SearchMatch fail with an *AmbiguousMatchError
+// when another candidate that doesn't overlap the match scores within
+// epsilon of it, or better. Only candidates within the maximum radius are
+// considered, and diffs with an Occurrence aren't checked.
+func WithAmbiguityCheck(epsilon float64) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.ambiguity = max(0, epsilon)
+	}
+}
+
+// WithTabWidth expands tabs to spaces, with tab stops every n columns,
+// before comparing text. Edits still refer to the original text.
+func WithTabWidth(n int) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.tabWidth = n
+	}
+}
+
+// WithIndentAgnostic makes the comparison ignore the indentation that all
+// of the lines have in common, while still comparing their relative
+// indentation. The replace text is re-indented to match the lines it
+// replaces, so patches for differently indented copies of code still apply.
+func WithIndentAgnostic() SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.indent = true
+	}
+}
+
+// WithMatchedIndent re-indents the replace text so that its least indented
+// lines line up with the least indented lines of the match, no matter how
+// the patch author indented it. Relative indentation within the replace text
+// is kept.
+func WithMatchedIndent() SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.keepIndent = true
+	}
+}
+
+// WithUnicodeNormalization puts the text into Unicode normalization form C
+// before comparing it, so composed and decomposed forms of the same
+// characters compare equal.
+func WithUnicodeNormalization() SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.nfc = true
+	}
+}
+
+// WithASCIIPunctuation makes curly quotes, dashes, ellipses, and unusual
+// spaces compare equal to their ASCII counterparts. This only affects the
+// comparison, so the edits still replace the original bytes.
+func WithASCIIPunctuation() SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.ascii = true
+	}
+}
+
+// asciiPunctuation maps punctuation to its ASCII look-alike.
+var asciiPunctuation = strings.NewReplacer(
+	"\u2018", "'", "\u2019", "'", "\u201A", "'", "\u201B", "'", "\u2032", "'",
+	"\u201C", `"`, "\u201D", `"`, "\u201E", `"`, "\u201F", `"`, "\u2033", `"`,
+	"\u2010", "-", "\u2011", "-", "\u2012", "-", "\u2013", "-", "\u2014", "-", "\u2015", "-", "\u2212", "-",
+	"\u2026", "...",
+	"\u00A0", " ", "\u2002", " ", "\u2003", " ", "\u2007", " ", "\u2009", " ", "\u200A", " ", "\u202F", " ",
+	"\u200B", "", "\uFEFF", "",
+)
+
+// WithAnchors makes the first and last n lines of the search text anchors.
+// Only the anchors are compared, so the lines between them can differ
+// arbitrarily, which lets hunks land when only their middle has changed.
+// Search text with 2n lines or fewer, or with ellipses, is compared as usual.
+func WithAnchors(n int) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.anchors = n
+	}
+}
+
+// WithHeightTolerance lets a match span up to k lines more or fewer than
+// the search text, so hunks still land when lines were added or removed,
+// such as a new blank line or comment. The height with the best score wins,
+// and ties go to the height closest to the search text's.
+func WithHeightTolerance(k int) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.heights = k
+	}
+}
+
+// literal reports whether only text identical to the search text can
+// score 1, so exact matches can be found without scoring candidates.
+func (cfg *searchConfig) literal() bool {
+	return !cfg.foldCase && !cfg.indent && !cfg.nfc && !cfg.ascii &&
+		cfg.anchors == 0 && cfg.whitespace == WhitespaceExact &&
+		cfg.comments == (CommentSyntax{}) && cfg.tabWidth <= 0
+}
+
+// normalize prepares text for comparison according to the options.
+func (cfg *searchConfig) normalize(s string) string {
+	if cfg.comments != (CommentSyntax{}) {
+		s = stripComments(s, cfg.comments)
+	}
+	if cfg.nfc {
+		s = norm.NFC.String(s)
+	}
+	if cfg.ascii {
+		s = asciiPunctuation.Replace(s)
+	}
+	if cfg.foldCase {
+		s = strings.ToLower(s)
+	}
+	if cfg.tabWidth > 0 {
+		s = expandTabs(s, cfg.tabWidth)
+	}
+	if cfg.indent {
+		s = reindent(s, commonIndent(trimSplit(s)), "")
+	}
+	if cfg.whitespace == WhitespaceExact {
+		return s
+	}
+	var b strings.Builder
+	for _, line := range trimSplit(s) {
+		switch cfg.whitespace {
+		case WhitespaceCollapse:
+			b.WriteString(strings.Join(strings.Fields(line), " "))
+		case WhitespaceInsensitive:
+			b.WriteString(collapseSpace(strings.TrimRightFunc(line, unicode.IsSpace)))
+		default:
+			b.WriteString(strings.TrimSpace(line))
+		}
+		if strings.HasSuffix(line, "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// ErrNoMatch is returned by SearchMatch when nothing satisfies the threshold.
+var ErrNoMatch = errors.New("no match")
+
+// NoMatchError is returned by SearchMatch instead of ErrNoMatch when the
+// WithClosest option is given. It matches ErrNoMatch with errors.Is.
+type NoMatchError struct {
+	Candidates []Candidate // The best scoring candidates, best first
+}
+
+func (e *NoMatchError) Error() string {
+	if len(e.Candidates) == 0 {
+		return ErrNoMatch.Error()
+	}
+	closest := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		closest[i] = c.String()
+	}
+	return fmt.Sprintf("%v: closest %s", ErrNoMatch, strings.Join(closest, ", "))
+}
+
+func (e *NoMatchError) Unwrap() error {
+	return ErrNoMatch
+}
+
+// ErrAmbiguousMatch is the cause of an AmbiguousMatchError.
+var ErrAmbiguousMatch = errors.New("ambiguous match")
+
+// AmbiguousMatchError is returned by SearchMatch when the WithAmbiguityCheck
+// option is given and more than one location matches equally well.
+type AmbiguousMatchError struct {
+	Matches []Match // The match that would have been used, followed by its rivals
+}
+
+func (e *AmbiguousMatchError) Error() string {
+	locations := make([]string, len(e.Matches))
+	for i, m := range e.Matches {
+		if m.LineEnd > m.Line {
+			locations[i] = fmt.Sprintf("lines %d-%d", m.Line, m.LineEnd)
+		} else {
+			locations[i] = fmt.Sprintf("line %d", m.Line)
+		}
+	}
+	return fmt.Sprintf("%v: %s", ErrAmbiguousMatch, strings.Join(locations, ", "))
+}
+
+func (e *AmbiguousMatchError) Unwrap() error {
+	return ErrAmbiguousMatch
+}
+
+// Match describes where Search found a diff's search text.
+type Match struct {
+	Edit     Edit    // The edit which replaces the matched text
+	Score    float64 // Similarity of the matched text, 1 for insertions
+	Line     int     // 1-based first line of the matched text
+	LineEnd  int     // 1-based last line of the matched text (inclusive), Line-1 for insertions
+	Distance int     // Number of lines between Line and the line hint, 0 without a hint
+}
+
+// commonIndent returns the leading whitespace shared by all the non-blank lines.
+func commonIndent(lines []string) string {
+	indent, first := "", true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			indent, first = lead, false
+			continue
+		}
+		n := 0
+		for n < len(indent) && n < len(lead) && indent[n] == lead[n] {
+			n++
+		}
+		indent = indent[:n]
+	}
+	return indent
+}
+
+// reindent replaces the from indentation of each non-blank line in s with to.
+// Lines that don't start with from are only given the new indentation.
+func reindent(s, from, to string) string {
+	if from == to {
+		return s
+	}
+	var b strings.Builder
+	for _, line := range trimSplit(s) {
+		if strings.TrimSpace(line) != "" {
+			b.WriteString(to)
+			line = strings.TrimPrefix(line, from)
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// expandTabs replaces the tabs in s with spaces up to the next multiple of
+// width columns.
+func expandTabs(s string, width int) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+	var b strings.Builder
+	col := 0
+	for _, r := range s {
+		switch r {
+		case '\t':
+			n := width - col%width
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		case '\n':
+			b.WriteRune(r)
+			col = 0
+		default:
+			b.WriteRune(r)
+			col++
+		}
+	}
+	return b.String()
+}
+
+// collapseSpace replaces each run of spaces and tabs in s with a single space.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
 }
 
 // Search tries to locate `diff.Search` inside `source`.
 // It begins at the requested line and expands alternately upward/downward
-// until a slice whose similarity ≥ threshold is found.
+// until a slice whose similarity ≥ the threshold is found, so the match is
+// always the nearest one to the hint. Of two candidates at the same distance,
+// the one above the hint is preferred unless WithTieBreak says otherwise.
+// When diff.Line is 0 the whole document is scanned from the top.
 //
-// Similarity = 1 - (levenshtein distance / maxLen).
+// Similarity is measured with the Levenshtein metric unless the WithMetric
+// option is given.
 // On success it returns the byte‑offset edit [Start, End) to replace and true.
 // If nothing satisfies the threshold it returns (zero Edit, false).
-func Search(source string, diff Diff, threshold float64) (Edit, bool) {
-	lines := trimSplit(source) // keep original EOLs
-	if len(lines) == 0 {
-		return Edit{}, false
+//
+// A non-zero `diff.Threshold` takes precedence over the WithThreshold and
+// WithThresholdPolicy options.
+// When `diff.LineEnd` is set, only the lines in [diff.Line, diff.LineEnd]
+// are considered.
+// When `diff.Occurrence` is set, the hint is ignored and the k'th
+// non-overlapping match from the top of the document is used instead.
+//
+// When the document uses CRLF line endings, the search and replace text are
+// converted to CRLF so that LF patches match and don't mix line endings.
+//
+// A UTF-8 byte order mark at the start of the document is ignored while
+// matching, and the returned edit never replaces it.
+//
+// With WithEllipsis, a "..." line in the middle of `diff.Search` matches any
+// number of lines. The lines it matched are substituted for the corresponding
+// "..." line in `diff.Replace`, so long regions can be edited by their first
+// and last lines. Otherwise "..." lines are matched as literal text.
+//
+// An empty `diff.Search` is an insertion: the returned edit inserts
+// `diff.Replace` at the start of the hinted line without matching anything.
+func Search(source string, diff Diff, opts ...SearchOption) (Edit, bool) {
+	m, err := SearchMatch(source, diff, opts...)
+	return m.Edit, err == nil
+}
+
+// SearchMatch is like Search, but it describes the match, and returns
+// ErrNoMatch if nothing satisfies the threshold.
+// An invalid regular expression in a Regex diff is returned as an error.
+func SearchMatch(source string, diff Diff, opts ...SearchOption) (Match, error) {
+	return SearchContext(context.Background(), source, diff, opts...)
+}
+
+// SearchContext is like SearchMatch, but it gives up and returns the
+// context's error once ctx is done.
+func SearchContext(ctx context.Context, source string, diff Diff, opts ...SearchOption) (Match, error) {
+	if split := newSearchConfig(opts).split; split != nil {
+		t := newTranslation(source, split)
+		m, err := SearchContext(ctx, t.source, t.diff(diff), append(slices.Clip(opts), t.option())...)
+		if err != nil {
+			return Match{}, t.err(err)
+		}
+		return t.match(m), nil
 	}
+	s := newSearcher(source, diff, opts)
+	s.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return Match{}, err
+	}
+	if s.diff.Search == "" {
+		m, ok := s.insertion()
+		if !ok {
+			return Match{}, s.noMatch()
+		}
+		return m, nil
+	}
+	if s.diff.Regex {
+		return s.regexMatch()
+	}
+	if s.diff.Occurrence > 0 {
+		// count non-overlapping matches from the top, ignoring the hint
+		n := 0
+		for i := s.lo; s.inRange(i); i++ {
+			if err := ctx.Err(); err != nil {
+				return Match{}, err
+			}
+			if m, ok := s.match(i); ok {
+				n++
+				if n == s.diff.Occurrence {
+					return m, nil
+				}
+				i = m.LineEnd - 1
+			}
+		}
+		return Match{}, s.noMatch()
+	}
+	// exact matches are found up front, so they don't need to be scored
+	exact := s.exactMatches()
+	var best Match
+	found := false
+	for i := range s.candidates() {
+		if exact[i] {
+			// nothing scores higher, so this is the best match too
+			best, found = s.exactMatch(i), true
+			break
+		}
+		if s.cfg.bestMatch && len(exact) > 0 {
+			continue
+		}
+		m, ok := s.match(i)
+		if !ok || (found && m.Score <= best.Score) {
+			continue
+		}
+		best, found = m, true
+		if !s.cfg.bestMatch || m.Score == 1 {
+			break
+		}
+	}
+	if s.err != nil {
+		return Match{}, s.err
+	}
+	if !found {
+		return Match{}, s.noMatch()
+	}
+	if s.cfg.ambiguity >= 0 {
+		rivals := s.rivals(best)
+		if s.err != nil {
+			return Match{}, s.err
+		}
+		if len(rivals) > 0 {
+			return Match{}, &AmbiguousMatchError{Matches: append([]Match{best}, rivals...)}
+		}
+	}
+	return best, nil
+}
+
+// SearchAll returns every match for diff.Search in source, sorted by score,
+// then by distance from the line hint, and then by the tie-break. Matches may
+// overlap, and diff.Occurrence is ignored.
+func SearchAll(source string, diff Diff, opts ...SearchOption) []Match {
+	if split := newSearchConfig(opts).split; split != nil {
+		t := newTranslation(source, split)
+		matches := SearchAll(t.source, t.diff(diff), append(slices.Clip(opts), t.option())...)
+		for i, m := range matches {
+			matches[i] = t.match(m)
+		}
+		return matches
+	}
+	s := newSearcher(source, diff, opts)
+	if s.diff.Search == "" {
+		if m, ok := s.insertion(); ok {
+			return []Match{m}
+		}
+		return nil
+	}
+	if s.diff.Regex {
+		matches, _ := s.regexMatches()
+		return s.nearest(matches)
+	}
+	var matches []Match
+	for i := range s.candidates() {
+		if m, ok := s.match(i); ok {
+			matches = append(matches, m)
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		if a.Score != b.Score {
+			return cmp.Compare(b.Score, a.Score)
+		}
+		return s.compareDistance(a, b)
+	})
+	return matches
+}
+
+// DiffResult is the outcome of searching for one of the diffs given to
+// SearchAllDiffs.
+type DiffResult struct {
+	Match Match
+	Err   error // the error from SearchMatch, if any
+}
+
+// SearchAllDiffs calls SearchMatch for each of the diffs concurrently, and
+// returns the results in the same order as the diffs. Each diff is searched
+// for independently, so the matches may overlap. Custom metrics and locators
+// must be safe to call from multiple goroutines.
+func SearchAllDiffs(source string, diffs []Diff, opts ...SearchOption) []DiffResult {
+	results := make([]DiffResult, len(diffs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(diffs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				m, err := SearchMatch(source, diffs[i], opts...)
+				results[i] = DiffResult{Match: m, Err: err}
+			}
+		}()
+	}
+	for i := range diffs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// searcher finds the matches for a single diff.
+type searcher struct {
+	cfg       *searchConfig
+	source    string
+	diff      Diff
+	lines     []string
+	offsets   []int      // offsets[i] is the start byte of line i
+	shift     int        // bytes before the source, such as a byte order mark
+	eol       string     // the document's line ending
+	hint      int        // 0-based line of the hint
+	threshold float64    // the threshold for this diff
+	segments  [][]string // the search lines between ellipses
+	lo, hi    int        // the range of lines that matches must fall within
+	regions   []Region   // the regions proposed by the locator
+	ctx       context.Context
+	err       error       // the context's error, if it stopped the candidates early
+	subLine   bool        // whether the search text is matched within lines
+	closest   []Candidate // the best scoring candidates, for a NoMatchError
+}
+
+func newSearcher(source string, diff Diff, opts []SearchOption) *searcher {
+	s := &searcher{cfg: newSearchConfig(opts), ctx: context.Background()}
+	if rest, ok := strings.CutPrefix(source, bom); ok {
+		source = rest
+		diff.Search = strings.TrimPrefix(diff.Search, bom)
+		s.shift = len(bom)
+	}
+	s.source = source
+	s.lines = trimSplit(source) // keep original EOLs
 
 	// cumulative byte offsets: offsets[i] == start byte of line i
-	offsets := make([]int, len(lines)+1)
-	for i, l := range lines {
-		offsets[i+1] = offsets[i] + len(l)
-	}
-
-	searchLines := trimSplit(diff.Search)
-	nSearch := len(searchLines)
-	if nSearch == 0 || nSearch > len(lines) {
-		return Edit{}, false
-	}
-
-	// clamp user hint into valid range
-	startIdx := diff.Line - 1
-	startIdx = max(0, min(startIdx, len(lines)-1))
-
-	for radius := 0; ; radius++ {
-		tried := false
-
-		// candidate above / at the hint
-		left := startIdx - radius
-		if left >= 0 && left+nSearch <= len(lines) {
-			tried = true
-			chunk := strings.Join(lines[left:left+nSearch], "")
-			if similarity(chunk, diff.Search) >= threshold {
-				return Edit{
-					Start: offsets[left],
-					End:   offsets[left+nSearch],
-					Text:  diff.Replace,
-				}, true
+	s.offsets = make([]int, len(s.lines)+1)
+	for i, l := range s.lines {
+		s.offsets[i+1] = s.offsets[i] + len(l)
+	}
+
+	if diff.Fingerprint != "" && diff.LineEnd == 0 && diff.Occurrence == 0 {
+		if i := fingerprintLine(s.lines, diff); i >= 0 {
+			diff.Line = i + 2
+		}
+	}
+	s.hint = diff.Line - 1
+	if diff.Line == EndOfFile {
+		s.hint = len(s.lines) - 1
+	}
+
+	// match and replace using the document's line endings
+	s.eol = lineEnding(s.lines)
+	diff.Search = withLineEnding(diff.Search, s.eol)
+	diff.Replace = withLineEnding(diff.Replace, s.eol)
+	if s.cfg.subLine && !diff.Regex && diff.Search != "" && len(trimSplit(diff.Search)) == 1 {
+		s.subLine = true
+		diff.Search = strings.TrimSuffix(diff.Search, s.eol)
+		diff.Replace = strings.TrimSuffix(diff.Replace, s.eol)
+	}
+	s.diff = diff
+
+	s.threshold = s.cfg.threshold
+	if s.cfg.policy != nil {
+		s.threshold = s.cfg.policy(diff.Search)
+	}
+	if diff.Threshold > 0 {
+		s.threshold = diff.Threshold
+	}
+	s.segments = [][]string{trimSplit(diff.Search)}
+	if s.cfg.ellipsis {
+		s.segments = splitEllipsis(s.segments[0])
+	}
+
+	if s.cfg.locator != nil {
+		s.regions = s.cfg.locator.Locate(source, diff)
+	}
+
+	s.lo, s.hi = 0, len(s.lines)
+	if diff.LineEnd > 0 {
+		s.lo = max(0, diff.Line-1)
+		s.hi = min(len(s.lines), diff.LineEnd)
+	}
+	if r := s.cfg.lineRange; r[1] > 0 {
+		s.lo = max(s.lo, r[0]-1)
+		s.hi = min(s.hi, r[1])
+	}
+	if r := s.cfg.byteRange; r[1] > 0 {
+		start, end := r[0]-s.shift, r[1]-s.shift
+		lo, _ := slices.BinarySearch(s.offsets, start)
+		hi, found := slices.BinarySearch(s.offsets, end)
+		if !found {
+			hi--
+		}
+		s.lo = max(s.lo, lo)
+		s.hi = min(s.hi, hi)
+	}
+	return s
+}
+
+// compareDistance orders matches by their distance from the hint, then by
+// their distance from the column hint, then by the tie-break, and finally by
+// where they start. Matches are in document order when there's no hint.
+func (s *searcher) compareDistance(a, b Match) int {
+	if a.Distance != b.Distance {
+		return a.Distance - b.Distance
+	}
+	if s.diff.Column > 0 && a.Line == b.Line {
+		if d := abs(s.column(a)-s.diff.Column) - abs(s.column(b)-s.diff.Column); d != 0 {
+			return d
+		}
+	}
+	if a.Line != b.Line {
+		if s.cfg.tieBreak == PreferBelow && s.diff.Line != 0 {
+			return b.Line - a.Line
+		}
+		return a.Line - b.Line
+	}
+	return a.Edit.Start - b.Edit.Start
+}
+
+// column returns the 1-based column where m starts.
+func (s *searcher) column(m Match) int {
+	return m.Edit.Start - s.shift - s.offsets[m.Line-1] + 1
+}
+
+// distance returns the number of lines between line i and the hint.
+func (s *searcher) distance(i int) int {
+	if s.diff.Line == 0 {
+		return 0
+	}
+	return abs(i - s.hint)
+}
+
+// inRange reports whether a match can start at line i.
+func (s *searcher) inRange(i int) bool {
+	end := i + len(s.segments[0])
+	return i >= s.lo && end <= s.hi && s.inRegion(i, end)
+}
+
+// start returns the line that the candidates expand from, which is the
+// hint clamped into the valid range.
+func (s *searcher) start() int {
+	return max(0, min(s.hint, len(s.lines)-len(s.segments[0])))
+}
+
+// maxRadius returns how far from the start candidates can be, 0 if there's no limit.
+func (s *searcher) maxRadius() int {
+	if s.diff.Line == 0 {
+		return 0
+	}
+	radius := s.cfg.maxRadius
+	if s.cfg.radiusFrac > 0 {
+		n := max(1, int(s.cfg.radiusFrac*float64(len(s.lines))))
+		if radius <= 0 || n < radius {
+			radius = n
+		}
+	}
+	return radius
+}
+
+// candidates yields the lines that a match could start at, beginning at
+// the hint and expanding alternately upward/downward.
+func (s *searcher) candidates() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		nSearch := len(s.segments[0])
+		if nSearch > len(s.lines) {
+			return
+		}
+		startIdx := s.start()
+		if s.cfg.strictHint {
+			// the start is only moved from the hint to fit an end of file hint
+			if s.diff.Line != 0 && (startIdx == s.hint || s.diff.Line == EndOfFile) && s.inRange(startIdx) {
+				yield(startIdx)
 			}
+			return
 		}
+		maxRadius := s.maxRadius()
+		for radius := 0; maxRadius <= 0 || radius <= maxRadius; radius++ {
+			if err := s.ctx.Err(); err != nil {
+				s.err = err
+				return
+			}
+			// candidates above / at the hint and below it, in order of preference
+			candidates := []int{startIdx - radius, startIdx + radius}
+			if s.cfg.tieBreak == PreferBelow {
+				candidates[0], candidates[1] = candidates[1], candidates[0]
+			}
+			if radius == 0 { // both candidates are the same line
+				candidates = candidates[:1]
+			}
+			tried := false
+			for _, i := range candidates {
+				if i < 0 || i+nSearch > len(s.lines) {
+					continue
+				}
+				tried = true
+				if s.inRange(i) && !yield(i) {
+					return
+				}
+			}
+			if !tried { // both directions are out of range, give up
+				return
+			}
+		}
+	}
+}
 
-		// candidate below the hint (skip radius 0 = duplicate check)
-		right := startIdx + radius
-		if radius > 0 && right+nSearch <= len(lines) {
-			tried = true
-			chunk := strings.Join(lines[right:right+nSearch], "")
-			if similarity(chunk, diff.Search) >= threshold {
-				return Edit{
-					Start: offsets[right],
-					End:   offsets[right+nSearch],
-					Text:  diff.Replace,
-				}, true
+// fit returns the number of lines starting at line i that best match seg,
+// and their score. It returns a score of -1 if seg doesn't fit at line i.
+func (s *searcher) fit(i int, seg []string) (int, float64) {
+	height, best := len(seg), -1.0
+	for d := range 2*s.cfg.heights + 1 {
+		// try len(seg), len(seg)-1, len(seg)+1, ...
+		h := len(seg) + (d+1)/2
+		if d%2 == 1 {
+			h = len(seg) - (d+1)/2
+		}
+		if h < 1 || i+h > s.hi {
+			continue
+		}
+		if score := s.score(i, seg, h); score > best {
+			height, best = h, score
+		}
+	}
+	return height, best
+}
+
+// score returns the similarity of seg to the h lines starting at line i.
+// When anchors are enabled, it's the score of the worse anchor.
+func (s *searcher) score(i int, seg []string, h int) float64 {
+	if n := s.cfg.anchors; n > 0 && len(s.segments) == 1 && len(seg) > 2*n && h > 2*n {
+		head := s.compare(s.lines[i:i+n], seg[:n])
+		tail := s.compare(s.lines[i+h-n:i+h], seg[len(seg)-n:])
+		return min(head, tail)
+	}
+	return s.compare(s.lines[i:i+h], seg)
+}
+
+// compare returns the similarity of the lines to the search lines.
+func (s *searcher) compare(lines, search []string) float64 {
+	chunk := s.cfg.normalize(strings.Join(lines, ""))
+	return s.cfg.metric(chunk, s.cfg.normalize(strings.Join(search, "")))
+}
+
+// match returns the match starting at line i, if there is one, and records
+// the candidate.
+func (s *searcher) match(i int) (Match, bool) {
+	m, ok := s.try(i)
+	s.record(m, ok)
+	return m, ok
+}
+
+// try is match without recording the candidate.
+func (s *searcher) try(i int) (Match, bool) {
+	if s.subLine {
+		return s.subLineMatch(i)
+	}
+	return s.linesMatch(i)
+}
+
+// linesMatch returns the match of whole lines starting at line i. When
+// there isn't one, the returned match still has its lines and score.
+// The score of an ellipsis match is the score of its worst segment.
+func (s *searcher) linesMatch(i int) (Match, bool) {
+	height, best := s.fit(i, s.segments[0])
+	failed := Match{Score: best, Line: i + 1, LineEnd: i + height, Distance: s.distance(i)}
+	if best < s.threshold {
+		return failed, false
+	}
+	// each segment after an ellipsis matches at the first place it can
+	end := i + height
+	var gaps []string
+	for _, seg := range s.segments[1:] {
+		j := end
+		for ; j < s.hi; j++ {
+			if h, score := s.fit(j, seg); score >= s.threshold {
+				best = min(best, score)
+				height = h
+				break
 			}
 		}
+		if j >= s.hi {
+			return failed, false
+		}
+		gaps = append(gaps, strings.Join(s.lines[end:j], ""))
+		end = j + height
+	}
+	if s.excluded(s.offsets[i], s.offsets[end]) || !s.inRegion(i, end) || s.rejected(i, end) {
+		failed.LineEnd = end
+		return failed, false
+	}
+	return s.expand(Match{
+		Edit:     s.edit(i, end, fillEllipsis(s.replacement(i, end), gaps)),
+		Score:    best,
+		Line:     i + 1,
+		LineEnd:  end,
+		Distance: s.distance(i),
+	}), true
+}
+
+// edit returns the edit which replaces lines [i, end) with text, leaving out
+// the diff's context lines.
+func (s *searcher) edit(i, end int, text string) Edit {
+	start := min(i+s.diff.ContextBefore, end)
+	stop := max(end-s.diff.ContextAfter, start)
+	return Edit{Start: s.shift + s.offsets[start], End: s.shift + s.offsets[stop], Text: text}
+}
+
+// replacement returns the replace text for a match of lines [i, end).
+func (s *searcher) replacement(i, end int) string {
+	switch {
+	case s.cfg.keepIndent:
+		from := commonIndent(trimSplit(s.diff.Replace))
+		return reindent(s.diff.Replace, from, commonIndent(s.lines[i:end]))
+	case s.cfg.indent:
+		from := commonIndent(slices.Concat(s.segments...))
+		return reindent(s.diff.Replace, from, commonIndent(s.lines[i:end]))
+	default:
+		return s.diff.Replace
+	}
+}
 
-		if !tried { // both directions are out of range, give up
+// exactMatches returns the set of candidate lines where the search text
+// occurs exactly. It returns nil when the text needs to be normalized before
+// comparing it, or when it has ellipses.
+func (s *searcher) exactMatches() map[int]bool {
+	if !s.cfg.literal() || len(s.segments) > 1 || s.subLine {
+		return nil
+	}
+	var exact map[int]bool
+	for off := 0; off < len(s.source); {
+		k := strings.Index(s.source[off:], s.diff.Search)
+		if k < 0 {
 			break
 		}
+		start, end := off+k, off+k+len(s.diff.Search)
+		i, ok := slices.BinarySearch(s.offsets, start)
+		_, endOk := slices.BinarySearch(s.offsets, end)
+		inRadius := s.maxRadius() <= 0 || abs(i-s.start()) <= s.maxRadius()
+		if ok && endOk && s.inRange(i) && inRadius && !s.excluded(start, end) && !s.rejected(i, i+len(s.segments[0])) {
+			if exact == nil {
+				exact = map[int]bool{}
+			}
+			exact[i] = true
+		}
+		off = start + 1
 	}
-	return Edit{}, false
+	if s.cfg.strictHint {
+		// only the hinted line is a candidate
+		for i := range s.candidates() {
+			if exact[i] {
+				return map[int]bool{i: true}
+			}
+		}
+		return nil
+	}
+	return exact
+}
+
+// excluded reports whether the bytes [start, end) of the source overlap an
+// edit given to the WithExclude option.
+func (s *searcher) excluded(start, end int) bool {
+	start, stop := s.shift+start, s.shift+end
+	for _, e := range s.cfg.exclude {
+		if start < e.End && e.Start < stop {
+			return true
+		}
+	}
+	return false
+}
+
+// rejected reports whether lines [i, end), or the lines within the diff's
+// RejectRadius of them, contain any of the diff's rejected text.
+func (s *searcher) rejected(i, end int) bool {
+	if len(s.diff.Reject) == 0 {
+		return false
+	}
+	lo, hi := max(0, i-s.diff.RejectRadius), min(len(s.lines), end+s.diff.RejectRadius)
+	for _, line := range s.lines[lo:hi] {
+		for _, text := range s.diff.Reject {
+			if text = strings.TrimSpace(text); text != "" && strings.Contains(line, text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// exactMatch returns the match for an exact occurrence at line i.
+func (s *searcher) exactMatch(i int) Match {
+	end := i + len(s.segments[0])
+	m := s.expand(Match{
+		Edit:     s.edit(i, end, s.replacement(i, end)),
+		Score:    1,
+		Line:     i + 1,
+		LineEnd:  end,
+		Distance: s.distance(i),
+	})
+	s.record(m, true)
+	return m
+}
+
+// rivals returns the other candidates that score about as well as m, and
+// don't overlap it.
+func (s *searcher) rivals(m Match) []Match {
+	var rivals []Match
+	for i := range s.candidates() {
+		// the candidates were already recorded while searching for m
+		r, ok := s.try(i)
+		if !ok || r.Score < m.Score-s.cfg.ambiguity {
+			continue
+		}
+		if r.LineEnd < m.Line || r.Line > m.LineEnd {
+			rivals = append(rivals, r)
+		}
+	}
+	return rivals
+}
+
+// insertion returns the match for a diff without search text, and false if
+// it would insert the text inside of an edit given to WithExclude.
+func (s *searcher) insertion() (Match, bool) {
+	edit := insertion(s.source, s.offsets, s.diff, s.eol)
+	if s.excluded(edit.Start, edit.End) {
+		return Match{}, false
+	}
+	i := slices.Index(s.offsets, edit.Start)
+	edit.Start += s.shift
+	edit.End += s.shift
+	return s.expand(Match{Edit: edit, Score: 1, Line: i + 1, LineEnd: i, Distance: s.distance(i)}), true
+}
+
+// insertion returns an edit which inserts diff.Replace before the hinted line.
+// Hints past the end of the document append to it.
+func insertion(source string, offsets []int, diff Diff, eol string) Edit {
+	idx := max(0, min(diff.Line-1, len(offsets)-1))
+	if diff.Line == EndOfFile {
+		idx = len(offsets) - 1
+	}
+	text := diff.Replace
+	if idx == len(offsets)-1 && source != "" && !strings.HasSuffix(source, "\n") {
+		// the last line is unterminated, so start a new one
+		text = eol + text
+	}
+	return Edit{Start: offsets[idx], End: offsets[idx], Text: text}
 }
 
-// Apply performs all edits in one pass.
-// Edits are applied back‑to‑front so earlier byte offsets remain valid.
-func Apply(source string, edits []Edit) (string, error) {
+// Apply performs all of the edits in a single forward pass over the source.
+// All of the ranges are checked before anything is applied, and overlapping
+// edits are an error unless WithOverlap says otherwise. The edits can be in
+// any order, and the slice isn't modified. Only insertions at the same
+// offset depend on the order, and they're applied in the order given.
+func Apply(source string, edits []Edit, opts ...ApplyOption) (string, error) {
 	if len(edits) == 0 {
 		return source, nil
 	}
+	out, _, err := ApplyReport(source, edits, opts...)
+	return out, err
+}
+
+// lineEnding returns the line ending used by the first line.
+// Documents without a terminated first line are assumed to use LF.
+func lineEnding(lines []string) string {
+	if len(lines) > 0 && strings.HasSuffix(lines[0], "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// withLineEnding converts all of the line endings in s to eol.
+func withLineEnding(s, eol string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if eol != "\n" {
+		s = strings.ReplaceAll(s, "\n", eol)
+	}
+	return s
+}
 
-	// Apply from highest → lowest byte index.
-	sort.Slice(edits, func(i, j int) bool { return edits[i].Start > edits[j].Start })
+// ellipsis is a search line that matches any number of lines.
+const ellipsis = "..."
 
-	data := []byte(source)
-	lastEnd := len(data) // used to detect overlaps
+func isEllipsis(line string) bool {
+	return strings.TrimSpace(line) == ellipsis
+}
 
-	for _, e := range edits {
-		// range sanity
-		if e.Start < 0 || e.End < e.Start || e.End > len(data) {
-			return "", fmt.Errorf("invalid edit range [%d,%d)", e.Start, e.End)
-		}
-		if e.End > lastEnd { // overlap with a previously applied edit
-			return "", fmt.Errorf("overlapping edits at [%d,%d)", e.Start, e.End)
+// splitEllipsis splits the search lines into the segments between ellipsis
+// lines. Leading and trailing ellipses aren't wildcards, since there's
+// nothing to anchor them.
+func splitEllipsis(lines []string) [][]string {
+	segments := [][]string{{}}
+	for i, line := range lines {
+		if isEllipsis(line) && i > 0 && i < len(lines)-1 {
+			if len(segments[len(segments)-1]) > 0 {
+				segments = append(segments, []string{})
+			}
+			continue
 		}
+		segments[len(segments)-1] = append(segments[len(segments)-1], line)
+	}
+	return segments
+}
 
-		// splice: data = data[:Start] + e.Text + data[End:]
-		data = append(data[:e.Start], append([]byte(e.Text), data[e.End:]...)...)
-		lastEnd = e.Start // next edit must end before this
+// fillEllipsis replaces the ellipsis lines in replace with the lines that
+// the search ellipses matched, in order. Ellipses without a matching gap
+// are left as-is.
+func fillEllipsis(replace string, gaps []string) string {
+	if len(gaps) == 0 {
+		return replace
+	}
+	var b strings.Builder
+	for _, line := range trimSplit(replace) {
+		if isEllipsis(line) && len(gaps) > 0 {
+			b.WriteString(gaps[0])
+			gaps = gaps[1:]
+			continue
+		}
+		b.WriteString(line)
 	}
-	return string(data), nil
+	return b.String()
 }
 
-func similarity(a, b string) float64 {
-	if len(a) == 0 && len(b) == 0 {
-		return 1.0
+func abs(n int) int {
+	if n < 0 {
+		return -n
 	}
-	dist := levenshtein.ComputeDistance(a, b)
-	return 1 - float64(dist)/float64(max(len(a), len(b)))
+	return n
 }
 
 func trimSplit(s string) []string {
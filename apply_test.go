@@ -1,6 +1,10 @@
 package fuzzypatch
 
 import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -76,11 +80,176 @@ func TestSearch(t *testing.T) {
 				End:   20,
 			},
 		},
+		{
+			name:      "no line hint scans whole document",
+			source:    "foo\nbar\nbaz\n",
+			diff:      Diff{Search: "baz\n", Replace: "qux\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 8, End: 12, Text: "qux\n"},
+		},
+		{
+			name:      "multi-line search hinted at last line",
+			source:    "foo\nbar\nbaz\n",
+			diff:      Diff{Line: EndOfFile, Search: "bar\nbaz\n", Replace: "qux\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 4, End: 12, Text: "qux\n"},
+		},
+		{
+			name:      "occurrence ignores hint",
+			source:    "foo\nbar\nfoo\nbar\nfoo\n",
+			diff:      Diff{Line: 5, Occurrence: 2, Search: "foo\n", Replace: "baz\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 8, End: 12, Text: "baz\n"},
+		},
+		{
+			name:      "occurrence matches do not overlap",
+			source:    "a\na\na\na\n",
+			diff:      Diff{Occurrence: 2, Search: "a\na\n", Replace: "b\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 4, End: 8, Text: "b\n"},
+		},
+		{
+			name:      "occurrence out of range",
+			source:    "foo\nbar\n",
+			diff:      Diff{Occurrence: 2, Search: "foo\n", Replace: "baz\n"},
+			threshold: 1,
+			found:     false,
+		},
+		{
+			name:      "line range excludes nearer match",
+			source:    "foo\nbar\nbaz\nfoo\n",
+			diff:      Diff{Line: 2, LineEnd: 4, Search: "foo\n", Replace: "qux\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 12, End: 16, Text: "qux\n"},
+		},
+		{
+			name:      "match must fit inside line range",
+			source:    "foo\nbar\nbaz\n",
+			diff:      Diff{Line: 1, LineEnd: 2, Search: "bar\nbaz\n", Replace: "qux\n"},
+			threshold: 1,
+			found:     false,
+		},
+		{
+			name:      "occurrence within line range",
+			source:    "foo\nfoo\nfoo\nfoo\n",
+			diff:      Diff{Line: 2, LineEnd: 4, Occurrence: 2, Search: "foo\n", Replace: "qux\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 8, End: 12, Text: "qux\n"},
+		},
+		{
+			name:      "diff threshold is stricter",
+			source:    "hello wurld\n",
+			diff:      Diff{Line: 1, Threshold: 1, Search: "hello world\n", Replace: "goodbye world\n"},
+			threshold: 0.9,
+			found:     false,
+		},
+		{
+			name:      "diff threshold is looser",
+			source:    "hello wurld\n",
+			diff:      Diff{Line: 1, Threshold: 0.9, Search: "hello world\n", Replace: "goodbye world\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 0, End: 12, Text: "goodbye world\n"},
+		},
+		{
+			name:      "insertion at hinted line",
+			source:    "foo\nbar\n",
+			diff:      Diff{Line: 2, Replace: "baz\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 4, End: 4, Text: "baz\n"},
+		},
+		{
+			name:      "insertion without hint",
+			source:    "foo\nbar\n",
+			diff:      Diff{Replace: "baz\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 0, End: 0, Text: "baz\n"},
+		},
+		{
+			name:      "insertion past the end",
+			source:    "foo\nbar\n",
+			diff:      Diff{Line: 10, Replace: "baz\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 8, End: 8, Text: "baz\n"},
+		},
+		{
+			name:      "insertion after unterminated last line",
+			source:    "foo\nbar",
+			diff:      Diff{Line: 3, Replace: "baz\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 7, End: 7, Text: "\nbaz\n"},
+		},
+		{
+			name:      "append",
+			source:    "foo\nbar\n",
+			diff:      Diff{Line: EndOfFile, Replace: "baz\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 8, End: 8, Text: "baz\n"},
+		},
+		{
+			name:      "search from end of file",
+			source:    "foo\nbar\nfoo\nbar\n",
+			diff:      Diff{Line: EndOfFile, Search: "foo\n", Replace: "baz\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 8, End: 12, Text: "baz\n"},
+		},
+		{
+			name:      "insertion into empty source",
+			source:    "",
+			diff:      Diff{Line: 1, Replace: "baz\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 0, End: 0, Text: "baz\n"},
+		},
+		{
+			name:      "LF patch against CRLF source",
+			source:    "foo\r\nbar\r\nbaz\r\n",
+			diff:      Diff{Line: 2, Search: "bar\nbaz\n", Replace: "qux\nquux\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 5, End: 15, Text: "qux\r\nquux\r\n"},
+		},
+		{
+			name:      "append to unterminated CRLF source",
+			source:    "foo\r\nbar",
+			diff:      Diff{Line: EndOfFile, Replace: "baz\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 8, End: 8, Text: "\r\nbaz\r\n"},
+		},
+		{
+			name:      "source with byte order mark",
+			source:    "\uFEFFfoo\nbar\n",
+			diff:      Diff{Line: 1, Search: "foo\n", Replace: "baz\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 3, End: 7, Text: "baz\n"},
+		},
+		{
+			name:      "insertion after byte order mark",
+			source:    "\uFEFFfoo\n",
+			diff:      Diff{Line: 1, Replace: "bar\n"},
+			threshold: 1,
+			found:     true,
+			want:      Edit{Start: 3, End: 3, Text: "bar\n"},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			edit, ok := Search(tt.source, tt.diff, tt.threshold)
+			edit, ok := Search(tt.source, tt.diff, WithThreshold(tt.threshold))
 			assert.Equal(t, ok, tt.found)
 			if tt.found {
 				assert.DeepEqual(t, edit, tt.want)
@@ -89,6 +258,716 @@ func TestSearch(t *testing.T) {
 	}
 }
 
+func TestSearchOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		diff   Diff
+		opts   []SearchOption
+		found  bool
+		want   Edit
+	}{
+		{
+			name:   "default threshold",
+			source: "hello wurld\n",
+			diff:   Diff{Line: 1, Search: "hello world\n", Replace: "x\n"},
+			found:  true,
+			want:   Edit{Start: 0, End: 12, Text: "x\n"},
+		},
+		{
+			name:   "within max radius",
+			source: "a\nb\nc\nd\n",
+			diff:   Diff{Line: 1, Search: "c\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithMaxRadius(2)},
+			found:  true,
+			want:   Edit{Start: 4, End: 6, Text: "x\n"},
+		},
+		{
+			name:   "beyond max radius",
+			source: "a\nb\nc\nd\n",
+			diff:   Diff{Line: 1, Search: "d\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithMaxRadius(2)},
+			found:  false,
+		},
+		{
+			name:   "within max radius fraction",
+			source: "a\nb\nc\nd\n",
+			diff:   Diff{Line: 1, Search: "c\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithMaxRadiusFraction(0.5)},
+			found:  true,
+			want:   Edit{Start: 4, End: 6, Text: "x\n"},
+		},
+		{
+			name:   "beyond max radius fraction",
+			source: "a\nb\nc\nd\n",
+			diff:   Diff{Line: 1, Search: "d\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithMaxRadiusFraction(0.5)},
+			found:  false,
+		},
+		{
+			name:   "smaller radius limit applies",
+			source: "a\nb\nc\nd\n",
+			diff:   Diff{Line: 1, Search: "c\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithMaxRadius(3), WithMaxRadiusFraction(0.25)},
+			found:  false,
+		},
+		{
+			name:   "line range",
+			source: "foo\nbar\nfoo\n",
+			diff:   Diff{Line: 1, Search: "foo\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithLineRange(2, 3)},
+			found:  true,
+			want:   Edit{Start: 8, End: 12, Text: "x\n"},
+		},
+		{
+			name:   "outside line range",
+			source: "foo\nbar\nfoo\n",
+			diff:   Diff{Line: 1, Search: "foo\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithLineRange(2, 2)},
+			found:  false,
+		},
+		{
+			name:   "byte range",
+			source: "foo\nbar\nfoo\n",
+			diff:   Diff{Line: 1, Search: "foo\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithByteRange(2, 12)},
+			found:  true,
+			want:   Edit{Start: 8, End: 12, Text: "x\n"},
+		},
+		{
+			name:   "byte range excludes partial lines",
+			source: "foo\nbar\nfoo\n",
+			diff:   Diff{Line: 3, Search: "foo\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithByteRange(0, 11)},
+			found:  true,
+			want:   Edit{Start: 0, End: 4, Text: "x\n"},
+		},
+		{
+			name:   "byte range with BOM",
+			source: "\uFEFFfoo\nbar\nfoo\n",
+			diff:   Diff{Line: 1, Search: "foo\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithByteRange(7, 15)},
+			found:  true,
+			want:   Edit{Start: 11, End: 15, Text: "x\n"},
+		},
+		{
+			name:   "exclude earlier edit",
+			source: "foo\nbar\nfoo\n",
+			diff:   Diff{Line: 1, Search: "foo\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithExclude(Edit{Start: 0, End: 4})},
+			found:  true,
+			want:   Edit{Start: 8, End: 12, Text: "x\n"},
+		},
+		{
+			name:   "exclude fuzzy match",
+			source: "foa\nbar\nfob\n",
+			diff:   Diff{Line: 1, Search: "foo\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(0.5), WithExclude(Edit{Start: 2, End: 2})},
+			found:  true,
+			want:   Edit{Start: 8, End: 12, Text: "x\n"},
+		},
+		{
+			name:   "everything excluded",
+			source: "foo\nbar\nfoo\n",
+			diff:   Diff{Line: 1, Search: "foo\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithExclude(Edit{Start: 0, End: 4}, Edit{Start: 8, End: 12})},
+			found:  false,
+		},
+		{
+			name:   "case folding",
+			source: "Hello World\n",
+			diff:   Diff{Line: 1, Search: "hello world\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithCaseFolding()},
+			found:  true,
+			want:   Edit{Start: 0, End: 12, Text: "x\n"},
+		},
+		{
+			name:   "trim whitespace",
+			source: "\tfoo  \n\tbar\n",
+			diff:   Diff{Line: 1, Search: "foo\nbar\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithWhitespace(WhitespaceTrim)},
+			found:  true,
+			want:   Edit{Start: 0, End: 12, Text: "x\n"},
+		},
+		{
+			name:   "collapse whitespace",
+			source: "a  =   b\n",
+			diff:   Diff{Line: 1, Search: "a = b\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithWhitespace(WhitespaceCollapse)},
+			found:  true,
+			want:   Edit{Start: 0, End: 9, Text: "x\n"},
+		},
+		{
+			name:   "first match",
+			source: "hello wurld\nfoo\nhello world\n",
+			diff:   Diff{Line: 1, Search: "hello world\n", Replace: "x\n"},
+			found:  true,
+			want:   Edit{Start: 0, End: 12, Text: "x\n"},
+		},
+		{
+			name:   "best match",
+			source: "hello wurld\nfoo\nhello world\n",
+			diff:   Diff{Line: 1, Search: "hello world\n", Replace: "x\n"},
+			opts:   []SearchOption{WithBestMatch()},
+			found:  true,
+			want:   Edit{Start: 16, End: 28, Text: "x\n"},
+		},
+		{
+			name:   "best match within max radius",
+			source: "hello wurld\nfoo\nhello world\n",
+			diff:   Diff{Line: 1, Search: "hello world\n", Replace: "x\n"},
+			opts:   []SearchOption{WithBestMatch(), WithMaxRadius(1)},
+			found:  true,
+			want:   Edit{Start: 0, End: 12, Text: "x\n"},
+		},
+		{
+			name:   "fuzzy match nearer than exact match",
+			source: "foo\nhello wurld\nbar\nbaz\nhello world\n",
+			diff:   Diff{Line: 2, Search: "hello world\n", Replace: "x\n"},
+			found:  true,
+			want:   Edit{Start: 4, End: 16, Text: "x\n"},
+		},
+		{
+			name:   "exact match must be line aligned",
+			source: "say hello world\nhello world\n",
+			diff:   Diff{Line: 1, Search: "hello world\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1)},
+			found:  true,
+			want:   Edit{Start: 16, End: 28, Text: "x\n"},
+		},
+		{
+			name:   "whitespace insensitive skips a line with other indentation",
+			source: "x := 1\n\tx  :=\t1 \t\n",
+			diff:   Diff{Line: 1, Search: "  x := 1\n", Replace: "\tx := 2\n"},
+			opts:   []SearchOption{WithThreshold(1), WithWhitespace(WhitespaceInsensitive)},
+			found:  true,
+			want:   Edit{Start: 7, End: 18, Text: "\tx := 2\n"},
+		},
+		{
+			name:   "whitespace insensitive keeps indentation significant",
+			source: "x := 1\n",
+			diff:   Diff{Line: 1, Search: "\tx := 1\n", Replace: "y\n"},
+			opts:   []SearchOption{WithThreshold(1), WithWhitespace(WhitespaceInsensitive)},
+			found:  false,
+		},
+		{
+			name:   "indent agnostic",
+			source: "func f() {\n\tif x {\n\t\ty()\n\t}\n}\n",
+			diff:   Diff{Line: 2, Search: "if x {\n\ty()\n}\n", Replace: "if x {\n\ty()\n\tz()\n}\n"},
+			opts:   []SearchOption{WithThreshold(1), WithIndentAgnostic()},
+			found:  true,
+			want:   Edit{Start: 11, End: 28, Text: "\tif x {\n\t\ty()\n\t\tz()\n\t}\n"},
+		},
+		{
+			name:   "indent agnostic keeps relative indentation",
+			source: "\tif x {\n\ty()\n\t}\n",
+			diff:   Diff{Line: 1, Search: "if x {\n\ty()\n}\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithIndentAgnostic()},
+			found:  false,
+		},
+		{
+			name:   "ellipsis",
+			source: "func f() {\n\ta()\n\tb()\n\treturn\n}\n",
+			diff:   Diff{Line: 1, Search: "func f() {\n...\n}\n", Replace: "func g() {\n\t...\n}\n"},
+			opts:   []SearchOption{WithThreshold(1), WithEllipsis()},
+			found:  true,
+			want:   Edit{Start: 0, End: 31, Text: "func g() {\n\ta()\n\tb()\n\treturn\n}\n"},
+		},
+		{
+			name:   "ellipsis without replacement ellipsis",
+			source: "a\nb\nc\nd\n",
+			diff:   Diff{Line: 1, Search: "b\n...\nd\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithEllipsis()},
+			found:  true,
+			want:   Edit{Start: 2, End: 8, Text: "x\n"},
+		},
+		{
+			name:   "ellipsis matching no lines",
+			source: "a\nb\n",
+			diff:   Diff{Line: 1, Search: "a\n...\nb\n", Replace: "a\n...\nc\nb\n"},
+			opts:   []SearchOption{WithThreshold(1), WithEllipsis()},
+			found:  true,
+			want:   Edit{Start: 0, End: 4, Text: "a\nc\nb\n"},
+		},
+		{
+			name:   "ellipsis with missing tail",
+			source: "a\nb\nc\n",
+			diff:   Diff{Line: 1, Search: "a\n...\nz\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithEllipsis()},
+			found:  false,
+		},
+		{
+			name:   "leading ellipsis is literal",
+			source: "a\n...\nb\n",
+			diff:   Diff{Line: 1, Search: "...\nb\n", Replace: "c\n"},
+			opts:   []SearchOption{WithThreshold(1), WithEllipsis()},
+			found:  true,
+			want:   Edit{Start: 2, End: 8, Text: "c\n"},
+		},
+		{
+			name:   "ellipsis is literal by default",
+			source: "a\nb\nc\n",
+			diff:   Diff{Line: 1, Search: "a\n...\nc\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1)},
+			found:  false,
+		},
+		{
+			name:   "indent agnostic with ellipsis",
+			source: "    def f():\n        a()\n        b()\n",
+			diff:   Diff{Line: 1, Search: "def f():\n...\n    b()\n", Replace: "def g():\n...\n    b()\n"},
+			opts:   []SearchOption{WithThreshold(1), WithIndentAgnostic(), WithEllipsis()},
+			found:  true,
+			want:   Edit{Start: 0, End: 37, Text: "    def g():\n        a()\n        b()\n"},
+		},
+		{
+			name:   "decomposed characters",
+			source: "caf\u0065\u0301\n",
+			diff:   Diff{Line: 1, Search: "caf\u00e9\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1)},
+			found:  false,
+		},
+		{
+			name:   "unicode normalization",
+			source: "caf\u0065\u0301\n",
+			diff:   Diff{Line: 1, Search: "caf\u00e9\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithUnicodeNormalization()},
+			found:  true,
+			want:   Edit{Start: 0, End: 7, Text: "x\n"},
+		},
+		{
+			name:   "ascii punctuation",
+			source: "msg := \"don't \u2013 stop\"\n",
+			diff:   Diff{Line: 1, Search: "msg := \u201cdon\u2019t - stop\u201d\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithASCIIPunctuation()},
+			found:  true,
+			want:   Edit{Start: 0, End: 24, Text: "x\n"},
+		},
+		{
+			name:   "anchors",
+			source: "func f() {\n\tx := 1\n\ty := 2\n\treturn x + y\n}\n",
+			diff:   Diff{Line: 1, Search: "func f() {\n\ta()\n\tb()\n\tc()\n}\n", Replace: "func f() {}\n"},
+			opts:   []SearchOption{WithThreshold(1), WithAnchors(1)},
+			found:  true,
+			want:   Edit{Start: 0, End: 43, Text: "func f() {}\n"},
+		},
+		{
+			name:   "anchors must match",
+			source: "func g() {\n\tx := 1\n\ty := 2\n\treturn x + y\n}\n",
+			diff:   Diff{Line: 1, Search: "func f() {\n\ta()\n\tb()\n\tc()\n}\n", Replace: "func f() {}\n"},
+			opts:   []SearchOption{WithThreshold(1), WithAnchors(1)},
+			found:  false,
+		},
+		{
+			name:   "short search ignores anchors",
+			source: "a\nb\n",
+			diff:   Diff{Line: 1, Search: "a\nc\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithAnchors(1)},
+			found:  false,
+		},
+		{
+			name:   "gained a line",
+			source: "func f() {\n\ta()\n\n\tb()\n}\n",
+			diff:   Diff{Line: 1, Search: "func f() {\n\ta()\n\tb()\n}\n", Replace: "func f() {}\n"},
+			opts:   []SearchOption{WithThreshold(0.95), WithHeightTolerance(1)},
+			found:  true,
+			want:   Edit{Start: 0, End: 24, Text: "func f() {}\n"},
+		},
+		{
+			name:   "lost a line",
+			source: "func f() {\n\ta()\n}\nafter\n",
+			diff:   Diff{Line: 1, Search: "func f() {\n\ta()\n\n}\n", Replace: "func f() {}\n"},
+			opts:   []SearchOption{WithThreshold(0.9), WithHeightTolerance(1)},
+			found:  true,
+			want:   Edit{Start: 0, End: 18, Text: "func f() {}\n"},
+		},
+		{
+			name:   "gained a line without tolerance",
+			source: "func f() {\n\ta()\n\n\tb()\n}\n",
+			diff:   Diff{Line: 1, Search: "func f() {\n\ta()\n\tb()\n}\n", Replace: "func f() {}\n"},
+			opts:   []SearchOption{WithThreshold(0.95)},
+			found:  false,
+		},
+		{
+			name:   "adaptive threshold rejects short text",
+			source: "foo := 1\n",
+			diff:   Diff{Line: 1, Search: "foo := 2\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(0.8), WithThresholdPolicy(LengthAdaptive(0.8, 40))},
+			found:  false,
+		},
+		{
+			name:   "adaptive threshold allows long text",
+			source: "the quick brown fox jumps over the lazy cat\n",
+			diff:   Diff{Line: 1, Search: "the quick brown fox jumps over the lazy dog\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThresholdPolicy(LengthAdaptive(0.9, 40))},
+			found:  true,
+			want:   Edit{Start: 0, End: 44, Text: "x\n"},
+		},
+		{
+			name:   "diff threshold overrides policy",
+			source: "foo := 1\n",
+			diff:   Diff{Line: 1, Threshold: 0.8, Search: "foo := 2\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThresholdPolicy(LengthAdaptive(0.8, 40))},
+			found:  true,
+			want:   Edit{Start: 0, End: 9, Text: "x\n"},
+		},
+		{
+			name:   "matched indent",
+			source: "def f():\n    if x:\n        return 1\n",
+			diff:   Diff{Line: 2, Search: "    if x:\n        return 1\n", Replace: "if x:\n  return 2\nreturn 3\n"},
+			opts:   []SearchOption{WithMatchedIndent()},
+			found:  true,
+			want:   Edit{Start: 9, End: 36, Text: "    if x:\n      return 2\n    return 3\n"},
+		},
+		{
+			name:   "matched indent with fuzzy search",
+			source: "def f():\n    return 1\n",
+			diff:   Diff{Line: 2, Search: "  return 1\n", Replace: "\t\treturn 2\n"},
+			opts:   []SearchOption{WithThreshold(0.8), WithMatchedIndent()},
+			found:  true,
+			want:   Edit{Start: 9, End: 22, Text: "    return 2\n"},
+		},
+		{
+			name:   "tab width",
+			source: "all:\n\tgo build\n\t\t# x\ty\n",
+			diff:   Diff{Line: 2, Search: "    go build\n        # x y\n", Replace: "\tgo test\n"},
+			opts:   []SearchOption{WithThreshold(1), WithTabWidth(4)},
+			found:  true,
+			want:   Edit{Start: 5, End: 23, Text: "\tgo test\n"},
+		},
+		{
+			name:   "tab width mismatch",
+			source: "all:\n\tgo build\n",
+			diff:   Diff{Line: 2, Search: "    go build\n", Replace: "\tgo test\n"},
+			opts:   []SearchOption{WithThreshold(1), WithTabWidth(8)},
+			found:  false,
+		},
+		{
+			name:   "strict hint",
+			source: "foo\nbar\nfoo\n",
+			diff:   Diff{Line: 3, Search: "foo\n", Replace: "x\n"},
+			opts:   []SearchOption{WithStrictHint()},
+			found:  true,
+			want:   Edit{Start: 8, End: 12, Text: "x\n"},
+		},
+		{
+			name:   "strict hint off by one",
+			source: "foo\nbar\nfoo\n",
+			diff:   Diff{Line: 2, Search: "foo\n", Replace: "x\n"},
+			opts:   []SearchOption{WithStrictHint()},
+			found:  false,
+		},
+		{
+			name:   "strict hint past the end",
+			source: "foo\nbar\n",
+			diff:   Diff{Line: 2, Search: "foo\nbar\n", Replace: "x\n"},
+			opts:   []SearchOption{WithStrictHint()},
+			found:  false,
+		},
+		{
+			name:   "strict hint at end of file",
+			source: "foo\nbar\n",
+			diff:   Diff{Line: EndOfFile, Search: "foo\nbar\n", Replace: "x\n"},
+			opts:   []SearchOption{WithStrictHint()},
+			found:  true,
+			want:   Edit{Start: 0, End: 8, Text: "x\n"},
+		},
+		{
+			name:   "strict hint without hint",
+			source: "foo\n",
+			diff:   Diff{Search: "foo\n", Replace: "x\n"},
+			opts:   []SearchOption{WithStrictHint()},
+			found:  false,
+		},
+		{
+			name:   "strict hint with an exact match elsewhere",
+			source: "fooo\nfoo\n",
+			diff:   Diff{Line: 1, Search: "foo\n", Replace: "x\n"},
+			opts:   []SearchOption{WithStrictHint(), WithBestMatch(), WithThreshold(0.5)},
+			found:  true,
+			want:   Edit{Start: 0, End: 5, Text: "x\n"},
+		},
+		{
+			name:   "strict hint regex",
+			source: "v1\nv2\n",
+			diff:   Diff{Line: 2, Regex: true, Search: `v\d`, Replace: "v3"},
+			opts:   []SearchOption{WithStrictHint()},
+			found:  true,
+			want:   Edit{Start: 3, End: 5, Text: "v3"},
+		},
+		{
+			name:   "context lines",
+			source: "func a() {\n\treturn 1\n}\n\nfunc b() {\n\treturn 1\n}\n",
+			diff:   Diff{Line: 5, Search: "func b() {\n\treturn 1\n}\n", Replace: "\treturn 2\n", ContextBefore: 1, ContextAfter: 1},
+			found:  true,
+			want:   Edit{Start: 35, End: 45, Text: "\treturn 2\n"},
+		},
+		{
+			name:   "only context lines",
+			source: "a\nb\n",
+			diff:   Diff{Search: "a\nb\n", Replace: "x\n", ContextBefore: 1, ContextAfter: 2},
+			found:  true,
+			want:   Edit{Start: 2, End: 2, Text: "x\n"},
+		},
+		{
+			name:   "reject nearby text",
+			source: "mu.Lock()\nx++\nmu.Unlock()\n\ny++\n",
+			diff:   Diff{Line: 1, Search: "x++\n", Replace: "mu.Lock()\nx++\nmu.Unlock()\n", Reject: []string{"mu.Lock()"}, RejectRadius: 1},
+			opts:   []SearchOption{WithThreshold(0.5)},
+			found:  true,
+			want:   Edit{Start: 27, End: 31, Text: "mu.Lock()\nx++\nmu.Unlock()\n"},
+		},
+		{
+			name:   "reject within match",
+			source: "a\nb\n",
+			diff:   Diff{Line: 1, Search: "a\nb\n", Replace: "x\n", Reject: []string{"  b  "}},
+			found:  false,
+		},
+		{
+			name:   "reject outside radius",
+			source: "guard\n\n\nx\n",
+			diff:   Diff{Line: 4, Search: "x\n", Replace: "y\n", Reject: []string{"guard"}, RejectRadius: 1},
+			found:  true,
+			want:   Edit{Start: 8, End: 10, Text: "y\n"},
+		},
+		{
+			name:   "prefer above",
+			source: "foo\nbar\nfoo\n",
+			diff:   Diff{Line: 2, Search: "foo\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1)},
+			found:  true,
+			want:   Edit{Start: 0, End: 4, Text: "x\n"},
+		},
+		{
+			name:   "prefer below",
+			source: "foo\nbar\nfoo\n",
+			diff:   Diff{Line: 2, Search: "foo\n", Replace: "x\n"},
+			opts:   []SearchOption{WithThreshold(1), WithTieBreak(PreferBelow)},
+			found:  true,
+			want:   Edit{Start: 8, End: 12, Text: "x\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edit, ok := Search(tt.source, tt.diff, tt.opts...)
+			assert.Equal(t, ok, tt.found)
+			if tt.found {
+				assert.DeepEqual(t, edit, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		diff   Diff
+		match  Match
+		err    error
+	}{
+		{
+			name:   "exact match at hint",
+			source: "foo\nbar\nbaz\n",
+			diff:   Diff{Line: 2, Search: "bar\nbaz\n", Replace: "x\n"},
+			match: Match{
+				Edit:    Edit{Start: 4, End: 12, Text: "x\n"},
+				Score:   1,
+				Line:    2,
+				LineEnd: 3,
+			},
+		},
+		{
+			name:   "fuzzy match away from hint",
+			source: "a\nb\nc\nhello wurld\n",
+			diff:   Diff{Line: 1, Search: "hello world\n", Replace: "x\n"},
+			match: Match{
+				Edit:     Edit{Start: 6, End: 18, Text: "x\n"},
+				Score:    1 - 1.0/12,
+				Line:     4,
+				LineEnd:  4,
+				Distance: 3,
+			},
+		},
+		{
+			name:   "without hint",
+			source: "a\nb\n",
+			diff:   Diff{Search: "b\n", Replace: "x\n"},
+			match: Match{
+				Edit:    Edit{Start: 2, End: 4, Text: "x\n"},
+				Score:   1,
+				Line:    2,
+				LineEnd: 2,
+			},
+		},
+		{
+			name:   "insertion",
+			source: "a\nb\n",
+			diff:   Diff{Line: 2, Replace: "x\n"},
+			match: Match{
+				Edit:    Edit{Start: 2, End: 2, Text: "x\n"},
+				Score:   1,
+				Line:    2,
+				LineEnd: 1,
+			},
+		},
+		{
+			name:   "no match",
+			source: "a\nb\n",
+			diff:   Diff{Line: 1, Search: "zzz\n"},
+			err:    ErrNoMatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := SearchMatch(tt.source, tt.diff)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, m, tt.match)
+		})
+	}
+}
+
+func TestSearchAll(t *testing.T) {
+	source := "foo\nbar\nfoa\nfoo\nbaz\n"
+	matches := SearchAll(source, Diff{Line: 3, Search: "foo\n", Replace: "x\n"}, WithThreshold(0.5))
+	assert.DeepEqual(t, matches, []Match{
+		{Edit: Edit{Start: 12, End: 16, Text: "x\n"}, Score: 1, Line: 4, LineEnd: 4, Distance: 1},
+		{Edit: Edit{Start: 0, End: 4, Text: "x\n"}, Score: 1, Line: 1, LineEnd: 1, Distance: 2},
+		{Edit: Edit{Start: 8, End: 12, Text: "x\n"}, Score: 0.75, Line: 3, LineEnd: 3},
+	})
+
+	strict := SearchAll(source, Diff{Line: 3, Threshold: 1, Search: "foo\n", Replace: "x\n"}, WithThreshold(0.5))
+	assert.Equal(t, len(strict), 2)
+
+	assert.Equal(t, len(SearchAll(source, Diff{Search: "zzz\n"})), 0)
+}
+
+func TestSearchAllDiffs(t *testing.T) {
+	source := "foo\nbar\nbaz\n"
+	var diffs []Diff
+	for range 50 {
+		diffs = append(diffs,
+			Diff{Line: 1, Search: "baz\n", Replace: "x\n"},
+			Diff{Line: 1, Search: "qux\n", Replace: "y\n"},
+			Diff{Line: 3, Search: "foo\n", Replace: "z\n"},
+		)
+	}
+	results := SearchAllDiffs(source, diffs, WithThreshold(1))
+	assert.Equal(t, len(results), len(diffs))
+	for i := 0; i < len(results); i += 3 {
+		assert.DeepEqual(t, results[i], DiffResult{
+			Match: Match{Edit: Edit{Start: 8, End: 12, Text: "x\n"}, Score: 1, Line: 3, LineEnd: 3, Distance: 2},
+		})
+		assert.ErrorIs(t, results[i+1].Err, ErrNoMatch)
+		assert.DeepEqual(t, results[i+2], DiffResult{
+			Match: Match{Edit: Edit{Start: 0, End: 4, Text: "z\n"}, Score: 1, Line: 1, LineEnd: 1, Distance: 2},
+		})
+	}
+	assert.Equal(t, len(SearchAllDiffs(source, nil)), 0)
+}
+
+func TestSearchTieBreak(t *testing.T) {
+	// every line but the hinted one scores the same
+	source := "foa\nfob\nbar\nfoc\nfod\n"
+	diff := Diff{Line: 3, Search: "foo\n", Replace: "x\n"}
+	tests := []struct {
+		name  string
+		opts  []SearchOption
+		line  int
+		order []int
+	}{
+		{
+			name:  "prefer above",
+			opts:  []SearchOption{WithThreshold(0.5)},
+			line:  2,
+			order: []int{2, 4, 1, 5},
+		},
+		{
+			name:  "prefer below",
+			opts:  []SearchOption{WithThreshold(0.5), WithTieBreak(PreferBelow)},
+			line:  4,
+			order: []int{4, 2, 5, 1},
+		},
+		{
+			name:  "best match prefers above",
+			opts:  []SearchOption{WithThreshold(0.5), WithBestMatch()},
+			line:  2,
+			order: []int{2, 4, 1, 5},
+		},
+		{
+			name:  "best match prefers below",
+			opts:  []SearchOption{WithThreshold(0.5), WithBestMatch(), WithTieBreak(PreferBelow)},
+			line:  4,
+			order: []int{4, 2, 5, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := SearchMatch(source, diff, tt.opts...)
+			assert.NilError(t, err)
+			assert.Equal(t, m.Line, tt.line)
+			var order []int
+			for _, m := range SearchAll(source, diff, tt.opts...) {
+				order = append(order, m.Line)
+			}
+			assert.DeepEqual(t, order, tt.order)
+		})
+	}
+}
+
+func TestSearchAmbiguity(t *testing.T) {
+	source := "foo\nbar\nfoo\nfoa\n"
+	diff := Diff{Line: 2, Search: "foo\n", Replace: "x\n"}
+
+	_, err := SearchMatch(source, diff, WithAmbiguityCheck(0))
+	assert.ErrorIs(t, err, ErrAmbiguousMatch)
+	assert.Error(t, err, "ambiguous match: line 1, line 3")
+	var aerr *AmbiguousMatchError
+	assert.Assert(t, errors.As(err, &aerr))
+	assert.Equal(t, len(aerr.Matches), 2)
+
+	_, err = SearchMatch(source, Diff{Line: 4, Search: "foa\n"}, WithThreshold(0.7), WithAmbiguityCheck(0.1))
+	assert.NilError(t, err)
+
+	_, err = SearchMatch(source, Diff{Line: 4, Search: "foa\n"}, WithThreshold(0.7), WithAmbiguityCheck(0.3))
+	assert.ErrorIs(t, err, ErrAmbiguousMatch)
+
+	_, err = SearchMatch(source, Diff{Occurrence: 2, Search: "foo\n"}, WithAmbiguityCheck(0))
+	assert.NilError(t, err)
+}
+
+func TestSearchContext(t *testing.T) {
+	source := "foo\nbar\nbaz\nfoo\n"
+	diff := Diff{Line: 2, Search: "foo\n", Replace: "x\n"}
+
+	m, err := SearchContext(context.Background(), source, diff)
+	assert.NilError(t, err)
+	assert.Equal(t, m.Line, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = SearchContext(ctx, source, diff)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// cancel part way through the search
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	metric := func(a, b string) float64 {
+		calls++
+		cancel()
+		return 0
+	}
+	_, err = SearchContext(ctx, source, Diff{Line: 2, Search: "qux\n"}, WithMetric(metric))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, calls, 1)
+}
+
 func TestApply(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -145,11 +1024,34 @@ func TestApply(t *testing.T) {
 			want:   "abcdef!",
 			err:    false,
 		},
+		{
+			name:   "invalid range after overlap",
+			source: "abcdef",
+			edits:  []Edit{{Start: 0, End: 3, Text: "x"}, {Start: 2, End: 4, Text: "y"}, {Start: 9, End: 10, Text: "z"}},
+			want:   "",
+			err:    true,
+		},
+		{
+			name:   "adjacent edits",
+			source: "abcdef",
+			edits:  []Edit{{Start: 4, End: 6, Text: "EF"}, {Start: 2, End: 4, Text: "CD"}, {Start: 0, End: 2, Text: "AB"}},
+			want:   "ABCDEF",
+			err:    false,
+		},
+		{
+			name:   "insertions at the same offset",
+			source: "ad",
+			edits:  []Edit{{Start: 1, End: 1, Text: "b"}, {Start: 1, End: 1, Text: "c"}},
+			want:   "abcd",
+			err:    false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			edits := slices.Clone(tt.edits)
 			got, err := Apply(tt.source, tt.edits)
+			assert.DeepEqual(t, tt.edits, edits)
 			if tt.err {
 				assert.Assert(t, err != nil)
 			} else {
@@ -159,3 +1061,16 @@ func TestApply(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkApply(b *testing.B) {
+	source := strings.Repeat("x", 80000)
+	edits := make([]Edit, len(source))
+	for i := range edits {
+		edits[i] = Edit{Start: i, End: i + 1, Text: "y"}
+	}
+	for b.Loop() {
+		if _, err := Apply(source, edits); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package fuzzypatch
+
+import (
+	"strings"
+	"unsafe"
+)
+
+// SearchBytes is like Search, but the source is a byte slice, which is
+// searched without copying it. The source must not be modified until it
+// returns, and a Locator given to it mustn't keep the source it's passed.
+func SearchBytes(source []byte, diff Diff, opts ...SearchOption) (Edit, bool) {
+	edit, ok := Search(view(source), diff, opts...)
+	// the text can refer to the source, such as when an ellipsis is filled in
+	edit.Text = strings.Clone(edit.Text)
+	return edit, ok
+}
+
+// ApplyBytes is like Apply, but the source and the result are byte slices.
+// The result is allocated once, and the source isn't modified.
+func ApplyBytes(source []byte, edits []Edit, opts ...ApplyOption) ([]byte, error) {
+	p, err := planEdits(view(source), edits, newApplyConfig(opts))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, p.size)
+	last := 0 // end of the previous edit in the source
+	for _, i := range p.order {
+		e := p.edits[i]
+		out = append(out, source[last:e.Start]...)
+		out = append(out, e.Text...)
+		last = e.End
+	}
+	return append(out, source[last:]...), nil
+}
+
+// view returns b as a string without copying it. The string is only valid
+// until b is modified.
+func view(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
@@ -0,0 +1,78 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSearchBytes(t *testing.T) {
+	source := []byte("func f() {\n\treturn 1\n}\n")
+	diff := Diff{Line: 2, Search: "func f() {\n...\n}\n", Replace: "func g() {\n...\n}\n"}
+	edit, ok := SearchBytes(source, diff, WithEllipsis())
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, edit, Edit{Start: 0, End: 23, Text: "func g() {\n\treturn 1\n}\n"})
+
+	// the edit's text doesn't change along with the source
+	copy(source[11:], "\tRETURN")
+	assert.Equal(t, edit.Text, "func g() {\n\treturn 1\n}\n")
+
+	_, ok = SearchBytes(source, Diff{Search: "missing\n"})
+	assert.Assert(t, !ok)
+}
+
+func TestApplyBytes(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		edits  []Edit
+		opts   []ApplyOption
+		want   string
+		err    bool
+	}{
+		{
+			name:   "no edits",
+			source: "hello\n",
+			want:   "hello\n",
+		},
+		{
+			name:   "edits",
+			source: "foo bar baz\n",
+			edits:  []Edit{{Start: 8, End: 11, Text: "qux"}, {Start: 0, End: 3, Text: "f"}, {Start: 4, End: 4, Text: "new "}},
+			want:   "f new bar qux\n",
+		},
+		{
+			name:   "overlap",
+			source: "abcdef",
+			edits:  []Edit{{Start: 0, End: 3, Text: "x"}, {Start: 2, End: 4, Text: "y"}},
+			err:    true,
+		},
+		{
+			name:   "skip overlap",
+			source: "abcdef",
+			edits:  []Edit{{Start: 0, End: 3, Text: "x"}, {Start: 2, End: 4, Text: "y"}},
+			opts:   []ApplyOption{WithOverlap(OverlapSkip)},
+			want:   "xdef",
+		},
+		{
+			name:   "invalid range",
+			source: "abc",
+			edits:  []Edit{{Start: 2, End: 9}},
+			err:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := []byte(tt.source)
+			got, err := ApplyBytes(source, tt.edits, tt.opts...)
+			if tt.err {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, string(got), tt.want)
+			assert.Equal(t, cap(got), len(tt.want))
+			assert.Equal(t, string(source), tt.source)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package fuzzypatch
+
+import (
+	"strings"
+	"unicode"
+)
+
+// CommentSyntax describes how a language writes comments.
+type CommentSyntax struct {
+	Line       string // starts a comment that runs to the end of the line
+	BlockStart string // starts a comment that runs until BlockEnd
+	BlockEnd   string
+}
+
+var (
+	// SlashComments is the comment syntax of C-like languages such as Go,
+	// Java, and JavaScript.
+	SlashComments = CommentSyntax{Line: "//", BlockStart: "/*", BlockEnd: "*/"}
+
+	// HashComments is the comment syntax of languages such as Python, Ruby,
+	// shell, and YAML.
+	HashComments = CommentSyntax{Line: "#"}
+)
+
+// WithoutComments ignores comments when comparing text, and lines that only
+// contain a comment are left out entirely. Comment markers inside of string
+// literals aren't recognized, so they're treated as comments too. Combine it
+// with WithHeightTolerance to match regions that gained or lost comment lines.
+func WithoutComments(syntax CommentSyntax) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.comments = syntax
+	}
+}
+
+// stripComments removes the comments written in syntax from s.
+func stripComments(s string, syntax CommentSyntax) string {
+	var b strings.Builder
+	inBlock := false
+	for _, line := range trimSplit(s) {
+		text, eol := strings.CutSuffix(line, "\n")
+		var code strings.Builder
+		hasComment := inBlock
+		for text != "" {
+			if inBlock {
+				_, rest, ok := strings.Cut(text, syntax.BlockEnd)
+				text, inBlock = rest, !ok
+				continue
+			}
+			i, block := commentStart(text, syntax)
+			if i < 0 {
+				code.WriteString(text)
+				break
+			}
+			code.WriteString(text[:i])
+			hasComment = true
+			if !block {
+				break
+			}
+			text, inBlock = text[i+len(syntax.BlockStart):], true
+		}
+		rest := code.String()
+		if hasComment {
+			rest = strings.TrimRightFunc(rest, unicode.IsSpace)
+			if rest == "" {
+				continue // the line was only a comment
+			}
+		}
+		b.WriteString(rest)
+		if eol {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// commentStart returns the index of the first comment in text, and whether
+// it's a block comment. It returns -1 if there isn't one.
+func commentStart(text string, syntax CommentSyntax) (int, bool) {
+	line, block := -1, -1
+	if syntax.Line != "" {
+		line = strings.Index(text, syntax.Line)
+	}
+	if syntax.BlockStart != "" {
+		block = strings.Index(text, syntax.BlockStart)
+	}
+	if block >= 0 && (line < 0 || block < line) {
+		return block, true
+	}
+	return line, false
+}
@@ -0,0 +1,58 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestStripComments(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		syntax CommentSyntax
+		want   string
+	}{
+		{
+			name:   "line comment",
+			input:  "// doc\nx := 1 // one\n",
+			syntax: SlashComments,
+			want:   "x := 1\n",
+		},
+		{
+			name:   "block comment",
+			input:  "a /* b */ c\n/*\n * doc\n */\nd\n",
+			syntax: SlashComments,
+			want:   "a  c\nd\n",
+		},
+		{
+			name:   "hash comment",
+			input:  "# doc\nx = 1  # one\n\ny = 2\n",
+			syntax: HashComments,
+			want:   "x = 1\n\ny = 2\n",
+		},
+		{
+			name:   "no final newline",
+			input:  "x = 1 # one",
+			syntax: HashComments,
+			want:   "x = 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, stripComments(tt.input, tt.syntax), tt.want)
+		})
+	}
+}
+
+func TestWithoutComments(t *testing.T) {
+	source := "func f() {\n\t// increment x\n\tx++ // done\n}\n"
+	diff := Diff{Line: 1, Search: "func f() {\n\tx++\n}\n", Replace: "func f() {}\n"}
+
+	_, ok := Search(source, diff, WithThreshold(0.9))
+	assert.Assert(t, !ok)
+
+	edit, ok := Search(source, diff, WithThreshold(1), WithoutComments(SlashComments), WithHeightTolerance(1))
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, edit, Edit{Start: 0, End: len(source), Text: "func f() {}\n"})
+}
@@ -0,0 +1,106 @@
+package fuzzypatch
+
+import (
+	"strings"
+)
+
+const (
+	conflictOurs     = "<<<<<<<"
+	conflictBase     = "|||||||"
+	conflictSplit    = "======="
+	conflictTheirs   = ">>>>>>>"
+	conflictMarkSize = len(conflictOurs)
+)
+
+// ConflictSide is one side of a merge conflict.
+type ConflictSide int
+
+const (
+	Ours   ConflictSide = iota // The "<<<<<<<" side of a conflict
+	Theirs                     // The ">>>>>>>" side of a conflict
+)
+
+// ParseConflicts converts the merge conflicts in a file containing git
+// conflict markers into diffs:
+//
+//	<<<<<<< HEAD
+//	ours
+//	=======
+//	theirs
+//	>>>>>>> branch
+//
+// Each conflict becomes a Diff whose Search is the given side of the
+// conflict and whose Replace is the other side, so applying the diffs to
+// the from version of the file resolves every conflict in favor of the
+// other side. The line hints are relative to the from version. The base
+// section of diff3 style conflicts is ignored, and so are "=======" and
+// ">>>>>>>" lines outside of a conflict.
+func ParseConflicts(input string, from ConflictSide) ([]Diff, error) {
+	const (
+		outside = iota
+		inOurs
+		inBase
+		inTheirs
+	)
+	var diffs []Diff
+	var ours, theirs string
+	state := outside
+	line := 1 // 1-based line in the from version
+	start := 0
+	lineNo := 0
+	for text := range strings.Lines(input) {
+		lineNo++
+		marker := conflictMarker(text)
+		switch {
+		case state == outside && marker == conflictOurs:
+			state = inOurs
+			start = lineNo
+			ours, theirs = "", ""
+		case state == outside:
+			line++
+		case state == inOurs && marker == conflictBase:
+			state = inBase
+		case (state == inOurs || state == inBase) && marker == conflictSplit:
+			state = inTheirs
+		case state == inTheirs && marker == conflictTheirs:
+			state = outside
+			diff := Diff{Line: line, Search: ours, Replace: theirs, Span: Span{Start: start, End: lineNo}}
+			if from == Theirs {
+				diff.Search, diff.Replace = theirs, ours
+			}
+			diffs = append(diffs, diff)
+			line += strings.Count(diff.Search, "\n")
+		case marker != "":
+			return nil, &ParseError{Line: lineNo, Text: strings.TrimRight(text, "\r\n"), Msg: "unexpected conflict marker"}
+		case state == inOurs:
+			ours += text
+		case state == inTheirs:
+			theirs += text
+		}
+	}
+	if state != outside {
+		return nil, &ParseError{Line: start, Msg: "unterminated conflict", Err: ErrTruncated}
+	}
+	return diffs, nil
+}
+
+// conflictMarker returns the conflict marker that text starts with, if any.
+// Markers are followed by a label, or by nothing in the case of "=======".
+func conflictMarker(text string) string {
+	trim := strings.TrimRight(text, "\r\n")
+	if len(trim) < conflictMarkSize {
+		return ""
+	}
+	marker, rest := trim[:conflictMarkSize], trim[conflictMarkSize:]
+	switch marker {
+	case conflictSplit:
+		if rest == "" {
+			return marker
+		}
+	case conflictOurs, conflictBase, conflictTheirs:
+		if rest == "" || rest[0] == ' ' {
+			return marker
+		}
+	}
+	return ""
+}
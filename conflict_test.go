@@ -0,0 +1,78 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseConflicts(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		from  ConflictSide
+		diffs []Diff
+		err   bool
+	}{
+		{
+			name:  "no conflicts",
+			input: "foo\nbar\n",
+			diffs: nil,
+		},
+		{
+			name:  "ours to theirs",
+			input: "a\n<<<<<<< HEAD\nfoo\n=======\nbar\nbaz\n>>>>>>> branch\nb\n<<<<<<< HEAD\nqux\n=======\n>>>>>>> branch\n",
+			from:  Ours,
+			diffs: []Diff{
+				{Line: 2, Search: "foo\n", Replace: "bar\nbaz\n"},
+				{Line: 4, Search: "qux\n", Replace: ""},
+			},
+		},
+		{
+			name:  "theirs to ours",
+			input: "a\n<<<<<<< HEAD\nfoo\n=======\nbar\nbaz\n>>>>>>> branch\nb\n<<<<<<< HEAD\nqux\n=======\n>>>>>>> branch\n",
+			from:  Theirs,
+			diffs: []Diff{
+				{Line: 2, Search: "bar\nbaz\n", Replace: "foo\n"},
+				{Line: 5, Search: "", Replace: "qux\n"},
+			},
+		},
+		{
+			name:  "diff3 style",
+			input: "<<<<<<< ours\nfoo\n||||||| base\nold\n=======\nbar\n>>>>>>> theirs\n",
+			diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}},
+		},
+		{
+			name:  "marker-like text",
+			input: "<<<<<<<< not a marker\n=======x\n",
+			diffs: nil,
+		},
+		{
+			name:  "separator outside a conflict",
+			input: "Title\n=======\n",
+			diffs: nil,
+		},
+		{
+			name:  "nested conflict",
+			input: "<<<<<<< HEAD\n<<<<<<< HEAD\n",
+			err:   true,
+		},
+		{
+			name:  "unterminated conflict",
+			input: "<<<<<<< HEAD\nfoo\n=======\nbar\n",
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := ParseConflicts(tt.input, tt.from)
+			if tt.err {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, diffs, tt.diffs, ignoreSpans)
+		})
+	}
+}
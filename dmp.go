@@ -0,0 +1,156 @@
+package fuzzypatch
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var dmpHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@$`)
+
+// dmpMargin is the number of context characters written around each edit,
+// which matches diff-match-patch's default Patch_Margin.
+const dmpMargin = 4
+
+// ParseDMP parses the patch text produced by Google's diff-match-patch
+// library's patch_toText:
+//
+//	@@ -382,8 +481,9 @@
+//	 %0Ajumps
+//	-ed
+//	+s
+//	 over
+//
+// Each hunk becomes a Diff whose Search is the context and deleted text,
+// and whose Replace is the context and inserted text. The hunks are located
+// by character offset rather than by line, so the diffs don't have line
+// hints and their text usually starts and ends partway through a line.
+func ParseDMP(input string) ([]Diff, error) {
+	var diffs []Diff
+	var diff *Diff
+	lineNo := 0
+	for text := range strings.Lines(input) {
+		lineNo++
+		line := strings.TrimRight(text, "\r\n")
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "@@") {
+			if !dmpHeaderRe.MatchString(line) {
+				return nil, &ParseError{Line: lineNo, Text: line, Msg: "invalid hunk header"}
+			}
+			diffs = append(diffs, Diff{Span: Span{Start: lineNo, End: lineNo}})
+			diff = &diffs[len(diffs)-1]
+			continue
+		}
+		if diff == nil {
+			return nil, &ParseError{Line: lineNo, Text: line, Msg: "expected hunk header"}
+		}
+		s, err := url.PathUnescape(line[1:])
+		if err != nil {
+			return nil, &ParseError{Line: lineNo, Text: line, Msg: "invalid encoding", Err: err}
+		}
+		switch line[0] {
+		case ' ':
+			diff.Search += s
+			diff.Replace += s
+		case '-':
+			diff.Search += s
+		case '+':
+			diff.Replace += s
+		default:
+			return nil, &ParseError{Line: lineNo, Text: line, Msg: "unexpected line in hunk"}
+		}
+		diff.Span.End = lineNo
+	}
+	return diffs, nil
+}
+
+// FormatDMP returns the edits to source as diff-match-patch patch text.
+// Each edit becomes a hunk with a few characters of context on either side,
+// and the offsets are counted in runes.
+func FormatDMP(source string, edits []Edit) string {
+	edits = slices.Clone(edits)
+	slices.SortStableFunc(edits, func(a, b Edit) int { return a.Start - b.Start })
+	var b strings.Builder
+	delta := 0 // difference in length between the new and old text
+	for _, e := range edits {
+		if e.Start < 0 || e.End < e.Start || e.End > len(source) {
+			continue
+		}
+		before := lastRunes(source[:e.Start], dmpMargin)
+		after := firstRunes(source[e.End:], dmpMargin)
+		removed := source[e.Start:e.End]
+		start := utf8.RuneCountInString(source[:e.Start]) - utf8.RuneCountInString(before)
+		context := utf8.RuneCountInString(before) + utf8.RuneCountInString(after)
+		oldLen := context + utf8.RuneCountInString(removed)
+		newLen := context + utf8.RuneCountInString(e.Text)
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", dmpRange(start, oldLen), dmpRange(start+delta, newLen))
+		for _, part := range []struct{ op, text string }{
+			{" ", before},
+			{"-", removed},
+			{"+", e.Text},
+			{" ", after},
+		} {
+			if part.text != "" {
+				b.WriteString(part.op)
+				b.WriteString(dmpEscape(part.text))
+				b.WriteByte('\n')
+			}
+		}
+		delta += newLen - oldLen
+	}
+	return b.String()
+}
+
+// dmpRange formats the start and length of one side of a hunk header.
+func dmpRange(start, length int) string {
+	switch length {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return strconv.Itoa(start + 1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, length)
+	}
+}
+
+// dmpEscape percent-encodes s the same way as diff-match-patch, which uses
+// JavaScript's encodeURI but leaves spaces unencoded.
+func dmpEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			strings.IndexByte(" -_.!~*'();/?:@&=+$,#", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// firstRunes returns the first n runes of s.
+func firstRunes(s string, n int) string {
+	i := 0
+	for ; n > 0 && i < len(s); n-- {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return s[:i]
+}
+
+// lastRunes returns the last n runes of s.
+func lastRunes(s string, n int) string {
+	i := len(s)
+	for ; n > 0 && i > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	return s[i:]
+}
@@ -0,0 +1,118 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseDMP(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		diffs []Diff
+		err   bool
+	}{
+		{
+			name:  "empty input",
+			input: "",
+			diffs: nil,
+		},
+		{
+			name:  "single hunk",
+			input: "@@ -382,8 +481,9 @@\n %0Ajumps\n-ed\n+s\n  over\n",
+			diffs: []Diff{{Search: "\njumpsed over", Replace: "\njumpss over"}},
+		},
+		{
+			name:  "multiple hunks",
+			input: "@@ -1,5 +1,5 @@\n-a\n+b\n bcd\n@@ -10,0 +10,3 @@\n+100%25\n",
+			diffs: []Diff{
+				{Search: "abcd", Replace: "bbcd"},
+				{Replace: "100%"},
+			},
+		},
+		{
+			name:  "invalid header",
+			input: "@@ -x +1 @@\n-a\n",
+			err:   true,
+		},
+		{
+			name:  "missing header",
+			input: "-a\n",
+			err:   true,
+		},
+		{
+			name:  "invalid encoding",
+			input: "@@ -1 +1 @@\n-%zz\n",
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := ParseDMP(tt.input)
+			if tt.err {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, diffs, tt.diffs, ignoreSpans)
+		})
+	}
+}
+
+func TestFormatDMP(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		edits  []Edit
+		want   string
+	}{
+		{
+			name:   "no edits",
+			source: "hello",
+			want:   "",
+		},
+		{
+			name:   "replacement",
+			source: "the quick brown fox",
+			edits:  []Edit{{Start: 4, End: 9, Text: "slow"}},
+			want:   "@@ -1,13 +1,12 @@\n the \n-quick\n+slow\n  bro\n",
+		},
+		{
+			name:   "escaped text",
+			source: "a\nb",
+			edits:  []Edit{{Start: 1, End: 2, Text: "%\n"}},
+			want:   "@@ -1,3 +1,4 @@\n a\n-%0A\n+%25%0A\n b\n",
+		},
+		{
+			name:   "offsets shift after earlier edits",
+			source: "0123456789abcdefghij",
+			edits: []Edit{
+				{Start: 15, End: 15, Text: "XY"},
+				{Start: 0, End: 1},
+			},
+			want: "@@ -1,5 +1,4 @@\n-0\n 1234\n@@ -12,8 +11,10 @@\n bcde\n+XY\n fghi\n",
+		},
+		{
+			name:   "runes",
+			source: "héllo",
+			edits:  []Edit{{Start: 1, End: 3, Text: "e"}},
+			want:   "@@ -1,5 +1,5 @@\n h\n-%C3%A9\n+e\n llo\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, FormatDMP(tt.source, tt.edits), tt.want)
+		})
+	}
+}
+
+func TestFormatDMPRoundTrip(t *testing.T) {
+	source := "the quick brown fox\njumps over the lazy dog\n"
+	edits := []Edit{{Start: 20, End: 25, Text: "leaps"}}
+	diffs, err := ParseDMP(FormatDMP(source, edits))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, diffs, []Diff{{Search: "fox\njumps ove", Replace: "fox\nleaps ove"}}, ignoreSpans)
+}
@@ -0,0 +1,34 @@
+package fuzzypatch
+
+import "fmt"
+
+// DriftSummary describes how far a set of matches were from their line
+// hints, which shows when a patch generator's hints are going stale.
+type DriftSummary struct {
+	Matches int     // Number of matches
+	Drifted int     // Number of matches that weren't at their line hint
+	Max     int     // Largest distance from a line hint
+	Mean    float64 // Average distance from the line hints
+}
+
+func (d DriftSummary) String() string {
+	return fmt.Sprintf("%d of %d matches drifted, by up to %d lines (mean %.1f)", d.Drifted, d.Matches, d.Max, d.Mean)
+}
+
+// SummarizeDrift summarizes the Distance of each match from its line hint.
+func SummarizeDrift(matches []Match) DriftSummary {
+	var d DriftSummary
+	total := 0
+	for _, m := range matches {
+		d.Matches++
+		if m.Distance > 0 {
+			d.Drifted++
+		}
+		d.Max = max(d.Max, m.Distance)
+		total += m.Distance
+	}
+	if d.Matches > 0 {
+		d.Mean = float64(total) / float64(d.Matches)
+	}
+	return d
+}
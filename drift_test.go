@@ -0,0 +1,27 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSummarizeDrift(t *testing.T) {
+	source := "a\nb\nc\nd\ne\n"
+	var matches []Match
+	for _, diff := range []Diff{
+		{Line: 1, Search: "a\n"},
+		{Line: 1, Search: "d\n"},
+		{Line: 4, Search: "c\n"},
+		{Search: "e\n"},
+	} {
+		m, err := SearchMatch(source, diff)
+		assert.NilError(t, err)
+		matches = append(matches, m)
+	}
+	drift := SummarizeDrift(matches)
+	assert.DeepEqual(t, drift, DriftSummary{Matches: 4, Drifted: 2, Max: 3, Mean: 1})
+	assert.Equal(t, drift.String(), "2 of 4 matches drifted, by up to 3 lines (mean 1.0)")
+
+	assert.DeepEqual(t, SummarizeDrift(nil), DriftSummary{})
+}
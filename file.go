@@ -0,0 +1,47 @@
+package fuzzypatch
+
+import (
+	"encoding/binary"
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ParseFile reads and parses the patch in the named file. The file is
+// decoded before it's tokenized: UTF-16 is detected by its byte order mark,
+// and files that aren't valid UTF-8 are assumed to be Latin-1.
+func ParseFile(path string, opts ...ParseOption) ([]Diff, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(decodeText(data), opts...)
+}
+
+// decodeText converts text in a common encoding to UTF-8.
+func decodeText(data []byte) string {
+	switch {
+	case len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE:
+		return decodeUTF16(data[2:], binary.LittleEndian)
+	case len(data) >= 2 && data[0] == 0xFE && data[1] == 0xFF:
+		return decodeUTF16(data[2:], binary.BigEndian)
+	case utf8.Valid(data):
+		return string(data)
+	default:
+		// every byte is a valid Latin-1 character
+		var b strings.Builder
+		for _, c := range data {
+			b.WriteRune(rune(c))
+		}
+		return b.String()
+	}
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
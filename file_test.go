@@ -0,0 +1,48 @@
+package fuzzypatch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseFile(t *testing.T) {
+	const patch = "<<<<<<< SEARCH line:1\ncafé\n=======\nbar\n>>>>>>> REPLACE\n"
+	utf16le := []byte{0xFF, 0xFE}
+	utf16be := []byte{0xFE, 0xFF}
+	for _, u := range utf16.Encode([]rune(patch)) {
+		utf16le = append(utf16le, byte(u), byte(u>>8))
+		utf16be = append(utf16be, byte(u>>8), byte(u))
+	}
+	latin1 := []byte(strings.Replace(patch, "é", "\xe9", 1))
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "utf-8", data: []byte(patch)},
+		{name: "utf-8 with bom", data: []byte("\uFEFF" + patch)},
+		{name: "utf-16le", data: utf16le},
+		{name: "utf-16be", data: utf16be},
+		{name: "latin-1", data: latin1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "patch.txt")
+			assert.NilError(t, os.WriteFile(path, tt.data, 0o644))
+			diffs, err := ParseFile(path)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, diffs, []Diff{{Line: 1, Search: "café\n", Replace: "bar\n"}}, ignoreSpans)
+		})
+	}
+}
+
+func TestParseFileMissing(t *testing.T) {
+	_, err := ParseFile(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Assert(t, os.IsNotExist(err))
+}
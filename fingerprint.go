@@ -0,0 +1,46 @@
+package fuzzypatch
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// Fingerprint returns a short hash of a line of text that identifies it
+// regardless of its indentation and spacing.
+func Fingerprint(line string) string {
+	h := fnv.New32a()
+	h.Write([]byte(strings.Join(strings.Fields(line), " ")))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// ContextFingerprint returns the Fingerprint of the nearest non-blank line
+// above the 1-based line in source, such as the signature of the function a
+// diff changes. It returns "" if there isn't one.
+func ContextFingerprint(source string, line int) string {
+	lines := trimSplit(strings.TrimPrefix(source, bom))
+	for i := min(line, len(lines)+1) - 2; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) != "" {
+			return Fingerprint(lines[i])
+		}
+	}
+	return ""
+}
+
+// fingerprintLine returns the 0-based line with the diff's fingerprint that's
+// nearest to its line hint, or -1 if there isn't one.
+func fingerprintLine(lines []string, diff Diff) int {
+	best := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" || Fingerprint(line) != diff.Fingerprint {
+			continue
+		}
+		if diff.Line <= 0 {
+			return i
+		}
+		if best < 0 || abs(i+1-diff.Line) < abs(best+1-diff.Line) {
+			best = i
+		}
+	}
+	return best
+}
@@ -0,0 +1,61 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, Fingerprint("func f() {\n"), Fingerprint("  func  f()  {"))
+	assert.Assert(t, Fingerprint("func f() {\n") != Fingerprint("func g() {\n"))
+	assert.Equal(t, len(Fingerprint("x")), 8)
+
+	source := "package p\n\nfunc f() {\n\n\treturn\n}\n"
+	assert.Equal(t, ContextFingerprint(source, 5), Fingerprint("func f() {"))
+	assert.Equal(t, ContextFingerprint(source, 3), Fingerprint("package p"))
+	assert.Equal(t, ContextFingerprint(source, 1), "")
+	assert.Equal(t, ContextFingerprint(source, 100), Fingerprint("}"))
+}
+
+func TestSearchFingerprint(t *testing.T) {
+	source := "func f() {\n\treturn\n}\n\nfunc g() {\n\treturn\n}\n"
+	tests := []struct {
+		name string
+		diff Diff
+		want Edit
+	}{
+		{
+			name: "overrides line hint",
+			diff: Diff{Line: 1, Fingerprint: Fingerprint("func g() {"), Search: "\treturn\n", Replace: "\tpanic()\n"},
+			want: Edit{Start: 33, End: 41, Text: "\tpanic()\n"},
+		},
+		{
+			name: "without line hint",
+			diff: Diff{Fingerprint: Fingerprint("func g() {"), Search: "\treturn\n", Replace: "\tpanic()\n"},
+			want: Edit{Start: 33, End: 41, Text: "\tpanic()\n"},
+		},
+		{
+			name: "insertion",
+			diff: Diff{Fingerprint: Fingerprint("func g() {"), Replace: "\tg()\n"},
+			want: Edit{Start: 33, End: 33, Text: "\tg()\n"},
+		},
+		{
+			name: "nearest to line hint",
+			diff: Diff{Line: 2, Fingerprint: Fingerprint("}"), Replace: "x\n"},
+			want: Edit{Start: 21, End: 21, Text: "x\n"},
+		},
+		{
+			name: "unknown fingerprint",
+			diff: Diff{Line: 1, Fingerprint: "00000000", Search: "\treturn\n", Replace: "\tpanic()\n"},
+			want: Edit{Start: 11, End: 19, Text: "\tpanic()\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edit, ok := Search(source, tt.diff)
+			assert.Assert(t, ok)
+			assert.DeepEqual(t, edit, tt.want)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package fuzzypatch
+
+import (
+	"strconv"
+	"strings"
+)
+
+// String formats the diff as a SEARCH/REPLACE block.
+func (d Diff) String() string {
+	var b strings.Builder
+	writeDiff(&b, d)
+	return b.String()
+}
+
+// Format formats the diffs as SEARCH/REPLACE blocks which can be read back
+// with Parse. The blocks are separated by blank lines, and diffs that only
+// delete text use the DELETE form. Lines that look like markers are escaped
+// with a leading backslash. The format can't represent search or replace
+// text without a trailing newline, so one is added where it's missing.
+func Format(diffs []Diff) string {
+	var b strings.Builder
+	for i, d := range diffs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		writeDiff(&b, d)
+	}
+	return b.String()
+}
+
+// String formats the patch as file headers followed by their blocks,
+// which can be read back with ParsePatch.
+func (p Patch) String() string {
+	var b strings.Builder
+	for i, f := range p.Files {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		switch {
+		case f.Op == FileCreate:
+			b.WriteString(newFilePrefix + " " + f.Path + "\n")
+		case f.Op == FileDelete:
+			b.WriteString(deleteFilePrefix + " " + f.OldPath + "\n")
+		case f.Op == FileRename:
+			b.WriteString(renamePrefix + f.OldPath + " -> " + f.Path + "\n")
+		case f.Path != "":
+			b.WriteString(fileHeaderPrefix + " " + f.Path + "\n")
+		}
+		b.WriteString(Format(f.Diffs))
+	}
+	return b.String()
+}
+
+func writeDiff(b *strings.Builder, d Diff) {
+	if d.Comment != "" {
+		for line := range strings.Lines(d.Comment) {
+			b.WriteString(commentPrefix + " " + strings.TrimSuffix(line, "\n") + "\n")
+		}
+	}
+	b.WriteString(startSearchPrefix)
+	switch d.Line {
+	case 0:
+	case EndOfFile:
+		b.WriteString(" line:$")
+	default:
+		b.WriteString(" line:" + strconv.Itoa(d.Line))
+		if d.LineEnd != 0 {
+			b.WriteString("-" + strconv.Itoa(d.LineEnd))
+		}
+	}
+	if d.Fingerprint != "" {
+		b.WriteString(" fingerprint:" + d.Fingerprint)
+	}
+	if d.Column != 0 {
+		b.WriteString(" column:" + strconv.Itoa(d.Column))
+	}
+	if d.ContextBefore != 0 || d.ContextAfter != 0 {
+		b.WriteString(" context:" + strconv.Itoa(d.ContextBefore) + "," + strconv.Itoa(d.ContextAfter))
+	}
+	if d.Occurrence != 0 {
+		b.WriteString(" occurrence:" + strconv.Itoa(d.Occurrence))
+	}
+	for _, r := range d.Reject {
+		b.WriteString(" reject:" + strconv.Quote(r))
+	}
+	if d.RejectRadius != 0 {
+		b.WriteString(" reject_radius:" + strconv.Itoa(d.RejectRadius))
+	}
+	if d.Threshold != 0 {
+		b.WriteString(" threshold:" + strconv.FormatFloat(d.Threshold, 'g', -1, 64))
+	}
+	if d.Regex {
+		b.WriteString(" regex:true")
+	}
+	b.WriteString("\n")
+	writeText(b, d.Search)
+	if d.Replace == "" && d.Search != "" {
+		b.WriteString(endDelete + "\n")
+		return
+	}
+	b.WriteString(textSeparator + "\n")
+	writeText(b, d.Replace)
+	b.WriteString(endReplace + "\n")
+}
+
+func writeText(b *strings.Builder, s string) {
+	cfg := newParseConfig(nil)
+	for line := range strings.Lines(s) {
+		b.WriteString(cfg.escape(line))
+	}
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		b.WriteString("\n")
+	}
+}
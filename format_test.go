@@ -0,0 +1,92 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		diffs  []Diff
+		output string
+	}{
+		{
+			name:   "no diffs",
+			diffs:  nil,
+			output: "",
+		},
+		{
+			name:   "single diff",
+			diffs:  []Diff{{Line: 3, Search: "foo\n", Replace: "bar\n"}},
+			output: "<<<<<<< SEARCH line:3\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+		},
+		{
+			name: "multiple diffs",
+			diffs: []Diff{
+				{Line: 1, Search: "foo\n", Replace: "bar\n"},
+				{Search: "baz\n", Replace: ""},
+			},
+			output: "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+				"\n" +
+				"<<<<<<< SEARCH\nbaz\n>>>>>>> DELETE\n",
+		},
+		{
+			name:   "escaped markers",
+			diffs:  []Diff{{Line: 1, Search: "=======\n\\=======\n\\x\n", Replace: ">>>>>>> DELETE\n"}},
+			output: "<<<<<<< SEARCH line:1\n\\=======\n\\\\=======\n\\x\n=======\n\\>>>>>>> DELETE\n>>>>>>> REPLACE\n",
+		},
+		{
+			name:   "reject",
+			diffs:  []Diff{{Line: 2, Reject: []string{"guard()"}, RejectRadius: 1, Search: "foo\n", Replace: "bar\n"}},
+			output: "<<<<<<< SEARCH line:2 reject:\"guard()\" reject_radius:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+		},
+		{
+			name:   "missing trailing newline",
+			diffs:  []Diff{{Line: 1, Search: "foo", Replace: "bar"}},
+			output: "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := Format(tt.diffs)
+			assert.Equal(t, output, tt.output)
+			if len(tt.diffs) == 1 {
+				assert.Equal(t, tt.diffs[0].String(), tt.output)
+			}
+		})
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	diffs := []Diff{
+		{Line: 1, Search: "foo\nbar\n", Replace: "baz\n"},
+		{Line: 10, Search: "\n", Replace: "qux\n\n", Comment: "add qux\nafter the blank line"},
+		{Line: EndOfFile, Replace: "the end\n"},
+		{Line: 3, Occurrence: 2, Search: "dup\n", Replace: "once\n"},
+		{Line: 5, LineEnd: 9, Search: "bounded\n", Replace: "range\n"},
+		{Line: 7, Threshold: 0.85, Search: "strict\n", Replace: "loose\n"},
+		{Line: 2, Regex: true, Search: "v\\d+\n", Replace: "v2\n"},
+		{Line: 3, ContextBefore: 1, ContextAfter: 2, Search: "a\nb\nc\nd\n", Replace: "B\n"},
+		{Line: 9, Fingerprint: "1a2b3c4d", Search: "anchored\n", Replace: "\n"},
+		{Line: 4, Column: 12, Regex: true, Search: "a\n", Replace: "b\n"},
+		{Line: 6, Reject: []string{"if err != nil {", `say "hi"`}, RejectRadius: 2, Search: "x\n", Replace: "y\n"},
+		{Line: 8, Search: "<<<<<<< HEAD\n=======\n>>>>>>> REPLACE\n", Replace: "\\=======\n\\begin\n"},
+	}
+	parsed, err := Parse(Format(diffs), WithComments())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, parsed, diffs, ignoreSpans)
+
+	patch := Patch{Files: []FileDiff{
+		{Path: "a.txt", OldPath: "a.txt", Diffs: diffs},
+		{Path: "b.txt", OldPath: "b.txt", Diffs: diffs[:1]},
+		{Path: "c.txt", Op: FileCreate, Diffs: []Diff{{Replace: "new\n"}}},
+		{OldPath: "d.txt", Op: FileDelete},
+		{Path: "f.txt", OldPath: "e.txt", Op: FileRename},
+	}}
+	parsedPatch, err := ParsePatch(patch.String(), WithComments())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, parsedPatch, patch, ignoreSpans)
+}
@@ -0,0 +1,105 @@
+package fuzzypatch
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ParseGit parses `git diff` or `git format-patch` output into a list of
+// per-file diffs. Extended header lines (index, mode changes, renames) are
+// recorded on the FileDiff, anything before the first "diff --git" line
+// (such as email headers) is ignored, and binary files produce a FileDiff
+// without any diffs.
+func ParseGit(input string) ([]FileDiff, error) {
+	lines := slices.Collect(strings.Lines(input))
+	var files []FileDiff
+	for i := 0; i < len(lines); {
+		line := strings.TrimRight(lines[i], "\r\n")
+		if strings.HasPrefix(line, "diff --git ") {
+			file, ok := parseGitHeader(line)
+			if !ok {
+				return nil, &ParseError{Line: i + 1, Text: line, Msg: "invalid git header"}
+			}
+			files = append(files, file)
+			i++
+			continue
+		}
+		if len(files) == 0 {
+			i++
+			continue
+		}
+		file := &files[len(files)-1]
+		if strings.HasPrefix(line, "@@") {
+			diff, n, err := parseHunk(lines[i:], i)
+			if err != nil {
+				return nil, err
+			}
+			file.Diffs = append(file.Diffs, diff)
+			i += n
+			continue
+		}
+		if s, ok := strings.CutPrefix(line, "old mode "); ok {
+			file.OldMode = s
+		} else if s, ok := strings.CutPrefix(line, "new mode "); ok {
+			file.NewMode = s
+		} else if s, ok := strings.CutPrefix(line, "new file mode "); ok {
+			file.NewMode = s
+			file.OldPath = ""
+			file.Op = FileCreate
+		} else if s, ok := strings.CutPrefix(line, "deleted file mode "); ok {
+			file.OldMode = s
+			file.Path = ""
+			file.Op = FileDelete
+		} else if s, ok := strings.CutPrefix(line, "rename from "); ok {
+			file.OldPath = gitPath(s, "")
+			file.Op = FileRename
+		} else if s, ok := strings.CutPrefix(line, "rename to "); ok {
+			file.Path = gitPath(s, "")
+		} else if s, ok := strings.CutPrefix(line, "--- "); ok {
+			file.OldPath = gitPath(s, "a/")
+		} else if s, ok := strings.CutPrefix(line, "+++ "); ok {
+			file.Path = gitPath(s, "b/")
+		}
+		i++
+	}
+	return files, nil
+}
+
+// parseGitHeader parses a "diff --git a/old b/new" line.
+func parseGitHeader(line string) (FileDiff, bool) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if strings.HasPrefix(rest, `"`) {
+		oldPath, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return FileDiff{}, false
+		}
+		newPath := strings.TrimSpace(rest[len(oldPath):])
+		return FileDiff{
+			OldPath: gitPath(oldPath, "a/"),
+			Path:    gitPath(newPath, "b/"),
+		}, true
+	}
+	oldPath, newPath, ok := strings.Cut(rest, " b/")
+	if !ok || !strings.HasPrefix(oldPath, "a/") {
+		return FileDiff{}, false
+	}
+	return FileDiff{
+		OldPath: gitPath(oldPath, "a/"),
+		Path:    newPath,
+	}, true
+}
+
+// gitPath cleans up a path from a git header by removing quoting,
+// trailing timestamps, and the given prefix. /dev/null becomes "".
+func gitPath(s, prefix string) string {
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	} else if before, _, ok := strings.Cut(s, "\t"); ok {
+		s = before
+	}
+	if s == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(s, prefix)
+}
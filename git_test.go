@@ -0,0 +1,109 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseGit(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		files []FileDiff
+		err   bool
+	}{
+		{
+			name:  "empty input",
+			input: "",
+			files: nil,
+		},
+		{
+			name: "modified file",
+			input: "diff --git a/main.go b/main.go\n" +
+				"index 83db48f..bf269f4 100644\n" +
+				"--- a/main.go\n" +
+				"+++ b/main.go\n" +
+				"@@ -1 +1 @@\n" +
+				"-foo\n" +
+				"+bar\n",
+			files: []FileDiff{{
+				Path:    "main.go",
+				OldPath: "main.go",
+				Diffs:   []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}},
+			}},
+		},
+		{
+			name: "format-patch with multiple files",
+			input: "From 1234 Mon Sep 17 00:00:00 2001\n" +
+				"Subject: [PATCH] change\n" +
+				"\n" +
+				"---\n" +
+				"diff --git a/a.txt b/a.txt\n" +
+				"old mode 100644\n" +
+				"new mode 100755\n" +
+				"diff --git a/b.txt b/b.txt\n" +
+				"new file mode 100644\n" +
+				"--- /dev/null\n" +
+				"+++ b/b.txt\n" +
+				"@@ -0,0 +1 @@\n" +
+				"+hello\n" +
+				"-- \n" +
+				"2.40.0\n",
+			files: []FileDiff{
+				{
+					Path:    "a.txt",
+					OldPath: "a.txt",
+					OldMode: "100644",
+					NewMode: "100755",
+				},
+				{
+					Path:    "b.txt",
+					NewMode: "100644",
+					Op:      FileCreate,
+					Diffs:   []Diff{{Line: 1, Replace: "hello\n"}},
+				},
+			},
+		},
+		{
+			name: "deleted file",
+			input: "diff --git a/old.txt b/old.txt\n" +
+				"deleted file mode 100644\n" +
+				"--- a/old.txt\n" +
+				"+++ /dev/null\n" +
+				"@@ -1 +0,0 @@\n" +
+				"-bye\n",
+			files: []FileDiff{{
+				OldPath: "old.txt",
+				OldMode: "100644",
+				Op:      FileDelete,
+				Diffs:   []Diff{{Line: 1, Search: "bye\n"}},
+			}},
+		},
+		{
+			name: "rename",
+			input: "diff --git a/x.go b/y.go\n" +
+				"similarity index 100%\n" +
+				"rename from x.go\n" +
+				"rename to y.go\n",
+			files: []FileDiff{{Path: "y.go", OldPath: "x.go", Op: FileRename}},
+		},
+		{
+			name:  "invalid header",
+			input: "diff --git nonsense\n",
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := ParseGit(tt.input)
+			if tt.err {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, files, tt.files, ignoreSpans)
+		})
+	}
+}
@@ -4,7 +4,8 @@ go 1.24.2
 
 require (
 	github.com/agnivade/levenshtein v1.2.1
+	github.com/google/go-cmp v0.5.9
+	golang.org/x/text v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
 	gotest.tools/v3 v3.5.2
 )
-
-require github.com/google/go-cmp v0.5.9 // indirect
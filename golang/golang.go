@@ -0,0 +1,92 @@
+// Package golang matches Go source code by its tokens instead of its text,
+// so formatting, whitespace, and comment changes don't affect the score.
+package golang
+
+import (
+	"go/scanner"
+	"go/token"
+
+	"github.com/icholy/fuzzypatch"
+)
+
+// Search is like fuzzypatch.Search, but it compares Go tokens with Metric.
+// Options that change how text is normalized, such as WithCaseFolding,
+// still apply before the text is scanned.
+func Search(source string, diff fuzzypatch.Diff, opts ...fuzzypatch.SearchOption) (fuzzypatch.Edit, bool) {
+	return fuzzypatch.Search(source, diff, withMetric(opts)...)
+}
+
+// SearchMatch is like fuzzypatch.SearchMatch, but it compares Go tokens with
+// Metric.
+func SearchMatch(source string, diff fuzzypatch.Diff, opts ...fuzzypatch.SearchOption) (fuzzypatch.Match, error) {
+	return fuzzypatch.SearchMatch(source, diff, withMetric(opts)...)
+}
+
+// withMetric returns opts with Metric as the default metric.
+func withMetric(opts []fuzzypatch.SearchOption) []fuzzypatch.SearchOption {
+	return append([]fuzzypatch.SearchOption{fuzzypatch.WithMetric(Metric)}, opts...)
+}
+
+// Metric scores the similarity of two fragments of Go source by the edit
+// distance between their token sequences. Comments and the semicolons
+// inserted at line breaks are ignored, and the fragments don't need to be
+// complete declarations.
+func Metric(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	x, y := scan(a), scan(b)
+	if len(x) == 0 && len(y) == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(x, y))/float64(max(len(x), len(y)))
+}
+
+// lexeme is a token and its literal text.
+type lexeme struct {
+	tok token.Token
+	lit string
+}
+
+// scan returns the tokens in src. Scanning errors are ignored, since the
+// text being compared is usually an arbitrary slice of a file.
+func scan(src string) []lexeme {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	var s scanner.Scanner
+	s.Init(file, []byte(src), nil, 0)
+	var lexemes []lexeme
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return lexemes
+		}
+		if tok == token.SEMICOLON && lit != ";" {
+			continue // inserted at a line break or the end of the input
+		}
+		if !tok.IsLiteral() {
+			lit = ""
+		}
+		lexemes = append(lexemes, lexeme{tok, lit})
+	}
+}
+
+// editDistance returns the levenshtein distance between two sequences.
+func editDistance(a, b []lexeme) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			prev, row[j] = row[j], min(row[j]+1, row[j-1]+1, prev+cost)
+		}
+	}
+	return row[len(b)]
+}
@@ -0,0 +1,78 @@
+package golang
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/icholy/fuzzypatch"
+)
+
+func TestMetric(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{
+			name: "identical",
+			a:    "x := 1\n",
+			b:    "x := 1\n",
+			want: 1,
+		},
+		{
+			name: "formatting",
+			a:    "x:=map[string]int{\"a\":1}\n",
+			b:    "x := map[string]int{\"a\": 1}\n",
+			want: 1,
+		},
+		{
+			name: "comments",
+			a:    "// add one\nx++ // done\n",
+			b:    "x++\n",
+			want: 1,
+		},
+		{
+			name: "explicit semicolons",
+			a:    "a(); b()\n",
+			b:    "a()\nb()\n",
+			want: 1 - float64(1)/7,
+		},
+		{
+			name: "different literal",
+			a:    "f(1, 2)\n",
+			b:    "f(1, 3)\n",
+			want: 1 - float64(1)/6,
+		},
+		{
+			name: "empty",
+			a:    "// nothing\n",
+			b:    "",
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, Metric(tt.a, tt.b), tt.want)
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	source := "func add(a, b int) int {\n\treturn a+b // sum\n}\n"
+	diff := fuzzypatch.Diff{
+		Line:    1,
+		Search:  "func add(a,b int) int {\n    return a + b\n}\n",
+		Replace: "func add(a, b int) int { return a + b }\n",
+	}
+	edit, ok := Search(source, diff, fuzzypatch.WithThreshold(1))
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, edit, fuzzypatch.Edit{Start: 0, End: len(source), Text: diff.Replace})
+
+	_, ok = fuzzypatch.Search(source, diff, fuzzypatch.WithThreshold(1))
+	assert.Assert(t, !ok)
+
+	m, err := SearchMatch(source, diff)
+	assert.NilError(t, err)
+	assert.Equal(t, m.Score, 1.0)
+}
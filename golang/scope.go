@@ -0,0 +1,114 @@
+package golang
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/icholy/fuzzypatch"
+)
+
+// ErrNoDeclaration is returned when a file doesn't declare the given name.
+var ErrNoDeclaration = errors.New("declaration not found")
+
+// WithDeclaration returns a search option that restricts matches to the
+// declaration of name in the Go source file src. See Declaration for how
+// names are written.
+func WithDeclaration(src, name string) (fuzzypatch.SearchOption, error) {
+	start, end, err := Declaration(src, name)
+	if err != nil {
+		return nil, err
+	}
+	return fuzzypatch.WithByteRange(start, end), nil
+}
+
+// Declaration returns the byte range [start, end) of the top-level function,
+// method, or type named name in the Go source file src. The range includes
+// the declaration's doc comment, indentation, and final line ending. Methods
+// are named "Type.Method", and the receiver's pointer is ignored.
+func Declaration(src, name string) (int, int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return 0, 0, err
+	}
+	pos, endPos := lookup(file, name)
+	if !pos.IsValid() {
+		return 0, 0, fmt.Errorf("%w: %s", ErrNoDeclaration, name)
+	}
+	start, end := fset.Position(pos).Offset, fset.Position(endPos).Offset
+	// widen the range to whole lines so it includes the indentation
+	for start > 0 && (src[start-1] == ' ' || src[start-1] == '\t') {
+		start--
+	}
+	if end < len(src) && src[end] == '\n' {
+		end++
+	}
+	return start, end, nil
+}
+
+// lookup returns the position of the declaration of name in file, including
+// its doc comment. The positions aren't valid if there isn't one.
+func lookup(file *ast.File, name string) (token.Pos, token.Pos) {
+	recv, name, isMethod := strings.Cut(name, ".")
+	if !isMethod {
+		name, recv = recv, ""
+	}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Name.Name == name && receiver(decl) == recv {
+				return withDoc(decl, decl.Doc), decl.End()
+			}
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE || isMethod {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				if spec.Name.Name != name {
+					continue
+				}
+				if !decl.Lparen.IsValid() {
+					return withDoc(decl, decl.Doc), decl.End()
+				}
+				return withDoc(spec, spec.Doc), spec.End()
+			}
+		}
+	}
+	return token.NoPos, token.NoPos
+}
+
+// receiver returns the name of a method's receiver type, or "" for
+// functions.
+func receiver(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return ""
+	}
+	typ := decl.Recv.List[0].Type
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.IndexListExpr:
+			typ = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// withDoc returns the start of node, or of its doc comment if it has one.
+func withDoc(node ast.Node, doc *ast.CommentGroup) token.Pos {
+	if doc != nil {
+		return doc.Pos()
+	}
+	return node.Pos()
+}
@@ -0,0 +1,87 @@
+package golang
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/icholy/fuzzypatch"
+)
+
+const scopeSource = `package p
+
+func Foo() error {
+	return nil
+}
+
+// Bar does nothing.
+func Bar() error {
+	return nil
+}
+
+type (
+	A struct{}
+	B int
+)
+
+func (a *A) Foo() error {
+	return nil
+}
+`
+
+func TestDeclaration(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		err  error
+	}{
+		{
+			name: "Foo",
+			want: "func Foo() error {\n\treturn nil\n}\n",
+		},
+		{
+			name: "Bar",
+			want: "// Bar does nothing.\nfunc Bar() error {\n\treturn nil\n}\n",
+		},
+		{
+			name: "B",
+			want: "\tB int\n",
+		},
+		{
+			name: "A.Foo",
+			want: "func (a *A) Foo() error {\n\treturn nil\n}\n",
+		},
+		{
+			name: "Baz",
+			err:  ErrNoDeclaration,
+		},
+		{
+			name: "B.Foo",
+			err:  ErrNoDeclaration,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := Declaration(scopeSource, tt.name)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, scopeSource[start:end], tt.want)
+		})
+	}
+}
+
+func TestWithDeclaration(t *testing.T) {
+	scope, err := WithDeclaration(scopeSource, "Bar")
+	assert.NilError(t, err)
+	diff := fuzzypatch.Diff{Search: "\treturn nil\n", Replace: "\treturn errBar\n"}
+	edit, ok := fuzzypatch.Search(scopeSource, diff, scope)
+	assert.Assert(t, ok)
+	assert.Equal(t, scopeSource[:edit.Start], scopeSource[:strings.Index(scopeSource, "func Bar")]+"func Bar() error {\n")
+
+	_, err = WithDeclaration("not go", "Bar")
+	assert.ErrorContains(t, err, "expected 'package'")
+}
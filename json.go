@@ -0,0 +1,69 @@
+package fuzzypatch
+
+import "encoding/json"
+
+// patchFile is a single entry in the JSON and YAML representations of a Patch.
+type patchFile struct {
+	Path    string `json:"path,omitempty" yaml:"path,omitempty"`
+	OldPath string `json:"old_path,omitempty" yaml:"old_path,omitempty"`
+	OldMode string `json:"old_mode,omitempty" yaml:"old_mode,omitempty"`
+	NewMode string `json:"new_mode,omitempty" yaml:"new_mode,omitempty"`
+	Op      FileOp `json:"op,omitempty" yaml:"op,omitempty"`
+	Diffs   []Diff `json:"diffs,omitempty" yaml:"diffs,omitempty"`
+}
+
+// patchFiles returns the entries for the files in p. The old path is left out
+// when it's the same as the path.
+func patchFiles(p Patch) []patchFile {
+	entries := []patchFile{}
+	for _, f := range p.Files {
+		e := patchFile(f)
+		if e.OldPath == e.Path {
+			e.OldPath = ""
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// filePatch is the inverse of patchFiles.
+func filePatch(entries []patchFile) Patch {
+	var patch Patch
+	for _, e := range entries {
+		if e.OldPath == "" && e.Op != FileCreate {
+			e.OldPath = e.Path
+		}
+		patch.Files = append(patch.Files, FileDiff(e))
+	}
+	return patch
+}
+
+// MarshalJSON encodes the patch as a list of files, each with the diffs that
+// target it:
+//
+//	[{"path": "main.go", "diffs": [{"line": 1, "search": "foo\n", "replace": "bar\n"}]}]
+//
+// Files that are created, deleted, or renamed have an "op" of "create",
+// "delete", or "rename", and renamed or deleted files have an "old_path".
+func (p Patch) MarshalJSON() ([]byte, error) {
+	return json.Marshal(patchFiles(p))
+}
+
+// UnmarshalJSON decodes the format produced by MarshalJSON.
+func (p *Patch) UnmarshalJSON(data []byte) error {
+	var entries []patchFile
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	*p = filePatch(entries)
+	return nil
+}
+
+// ParseJSON parses a JSON patch document. See Patch.MarshalJSON for the format.
+func ParseJSON(data []byte) (Patch, error) {
+	var patch Patch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return Patch{}, err
+	}
+	return patch, nil
+}
@@ -0,0 +1,67 @@
+package fuzzypatch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestPatchJSON(t *testing.T) {
+	patch := Patch{Files: []FileDiff{
+		{Path: "a.txt", OldPath: "a.txt", Diffs: []Diff{
+			{Line: 1, Search: "foo\n", Replace: "bar\n"},
+			{Search: "baz\n", Replace: ""},
+		}},
+		{Path: "b.txt", OldPath: "b.txt", Diffs: []Diff{
+			{Line: 3, Search: "qux\n", Replace: "quux\n"},
+		}},
+	}}
+	data, err := json.Marshal(patch)
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), `[`+
+		`{"path":"a.txt","diffs":[{"line":1,"search":"foo\n","replace":"bar\n"},{"search":"baz\n","replace":""}]},`+
+		`{"path":"b.txt","diffs":[{"line":3,"search":"qux\n","replace":"quux\n"}]}]`)
+
+	parsed, err := ParseJSON(data)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, parsed, patch)
+}
+
+func TestPatchJSONFileOps(t *testing.T) {
+	patch := Patch{Files: []FileDiff{
+		{Path: "new.txt", Op: FileCreate},
+		{OldPath: "old.txt", OldMode: "100644", Op: FileDelete},
+		{Path: "b.txt", OldPath: "a.txt", Op: FileRename, Diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}}},
+		{Path: "run.sh", OldPath: "run.sh", OldMode: "100644", NewMode: "100755"},
+	}}
+	data, err := json.Marshal(patch)
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), `[`+
+		`{"path":"new.txt","op":"create"},`+
+		`{"old_path":"old.txt","old_mode":"100644","op":"delete"},`+
+		`{"path":"b.txt","old_path":"a.txt","op":"rename","diffs":[{"line":1,"search":"foo\n","replace":"bar\n"}]},`+
+		`{"path":"run.sh","old_mode":"100644","new_mode":"100755"}]`)
+
+	parsed, err := ParseJSON(data)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, parsed, patch)
+
+	_, err = ParseJSON([]byte(`[{"path": "a.txt", "op": "copy"}]`))
+	assert.Error(t, err, `invalid file op "copy"`)
+}
+
+func TestParseJSON(t *testing.T) {
+	_, err := ParseJSON([]byte(`{"path": "a.txt"}`))
+	assert.Assert(t, err != nil)
+
+	patch, err := ParseJSON([]byte(`[]`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, patch, Patch{})
+}
+
+func TestEditJSON(t *testing.T) {
+	data, err := json.Marshal(Edit{Start: 1, End: 4, Text: "x"})
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), `{"start":1,"end":4,"text":"x"}`)
+}
@@ -0,0 +1,44 @@
+package fuzzypatch
+
+// Region is an inclusive range of 1-based lines in a document.
+type Region struct {
+	Start int
+	End   int
+}
+
+// Locator proposes the regions of a document where a diff could match, such
+// as the syntax nodes found by a parser. Search only considers matches that
+// fall entirely within one of the regions, and scores them as usual.
+type Locator interface {
+	Locate(source string, diff Diff) []Region
+}
+
+// LocatorFunc adapts a function to the Locator interface.
+type LocatorFunc func(source string, diff Diff) []Region
+
+// Locate calls f(source, diff).
+func (f LocatorFunc) Locate(source string, diff Diff) []Region {
+	return f(source, diff)
+}
+
+// WithLocator restricts matches to the regions proposed by l. Nothing
+// matches if it doesn't propose any.
+func WithLocator(l Locator) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.locator = l
+	}
+}
+
+// inRegion reports whether lines [i, end) fall within one of the regions
+// proposed by the locator.
+func (s *searcher) inRegion(i, end int) bool {
+	if s.cfg.locator == nil {
+		return true
+	}
+	for _, r := range s.regions {
+		if i >= r.Start-1 && end <= r.End {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWithLocator(t *testing.T) {
+	source := "func a() {\n\treturn\n}\n\nfunc b() {\n\treturn\n}\n"
+	// propose the lines of func b
+	funcB := LocatorFunc(func(source string, diff Diff) []Region {
+		return []Region{{Start: 5, End: 7}}
+	})
+	tests := []struct {
+		name  string
+		diff  Diff
+		opts  []SearchOption
+		found bool
+		want  Edit
+	}{
+		{
+			name:  "exact match in region",
+			diff:  Diff{Line: 1, Search: "\treturn\n", Replace: "\treturn // b\n"},
+			opts:  []SearchOption{WithLocator(funcB)},
+			found: true,
+			want:  Edit{Start: 33, End: 41, Text: "\treturn // b\n"},
+		},
+		{
+			name:  "fuzzy match in region",
+			diff:  Diff{Line: 1, Search: "\treturn 1\n", Replace: "\treturn // b\n"},
+			opts:  []SearchOption{WithThreshold(0.7), WithLocator(funcB)},
+			found: true,
+			want:  Edit{Start: 33, End: 41, Text: "\treturn // b\n"},
+		},
+		{
+			name: "straddles region",
+			diff: Diff{Line: 1, Search: "\n\nfunc b() {\n", Replace: "x\n"},
+			opts: []SearchOption{WithLocator(funcB)},
+		},
+		{
+			name:  "regex in region",
+			diff:  Diff{Line: 1, Regex: true, Search: `return`, Replace: "panic()"},
+			opts:  []SearchOption{WithLocator(funcB)},
+			found: true,
+			want:  Edit{Start: 34, End: 40, Text: "panic()"},
+		},
+		{
+			name: "no regions",
+			diff: Diff{Line: 1, Search: "\treturn\n", Replace: "x\n"},
+			opts: []SearchOption{WithLocator(LocatorFunc(func(string, Diff) []Region { return nil }))},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edit, ok := Search(source, tt.diff, tt.opts...)
+			assert.Equal(t, ok, tt.found)
+			if tt.found {
+				assert.DeepEqual(t, edit, tt.want)
+			}
+		})
+	}
+}
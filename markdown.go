@@ -0,0 +1,164 @@
+package fuzzypatch
+
+import (
+	"errors"
+	"strings"
+)
+
+// ParseMarkdown extracts blocks from the fenced code blocks in Markdown text,
+// such as a chat transcript. Text outside of fences and fences that don't
+// contain any blocks or file directives are ignored. When the fence's info string names a file
+// (for example "```go main.go" or "```main.go"), the fence's blocks are grouped
+// under that path. FILE: headers inside a fence take precedence. With the
+// WithAider option, a path on the line before the fence is used as well.
+// A fence that's still open at the end of the text is parsed too, and when
+// it ends in the middle of a block, the error wraps ErrTruncated and the
+// blocks before it are returned.
+func ParseMarkdown(input string, opts ...ParseOption) (Patch, error) {
+	cfg := newParseConfig(opts)
+	var patch Patch
+	var (
+		fence   string // the opening fence, empty when outside of a fence
+		info    string
+		path    string
+		prev    string // the previous line outside of a fence
+		start   int    // the number of lines before the fence's content
+		content strings.Builder
+	)
+	lineNo := 0
+	for line := range strings.Lines(input) {
+		lineNo++
+		trim := strings.TrimSpace(line)
+		if fence == "" {
+			if marker := fenceMarker(trim); marker != "" {
+				fence = marker
+				info = strings.TrimSpace(trim[len(marker):])
+				path = fencePath(info)
+				if path == "" && cfg.aider && prev != "" && !strings.ContainsAny(prev, " \t") {
+					path = prev
+				}
+				content.Reset()
+				start = lineNo
+			}
+			prev = trim
+			continue
+		}
+		if strings.HasPrefix(trim, fence) && strings.Trim(trim, fence[:1]) == "" {
+			if err := parseFence(&patch, content.String(), path, start, cfg, opts); err != nil {
+				return truncated(patch, err)
+			}
+			fence = ""
+			prev = ""
+			continue
+		}
+		content.WriteString(line)
+	}
+	if fence != "" {
+		// the closing fence is missing, such as when the output was cut off
+		if err := parseFence(&patch, content.String(), path, start, cfg, opts); err != nil {
+			return truncated(patch, err)
+		}
+	}
+	return patch, nil
+}
+
+// truncated returns the patch along with err if it's ErrTruncated, like
+// ParsePatch does, and drops it otherwise.
+func truncated(patch Patch, err error) (Patch, error) {
+	if errors.Is(err, ErrTruncated) {
+		return patch, err
+	}
+	return Patch{}, err
+}
+
+// parseFence parses the blocks and file directives in a single fence and
+// adds them to the patch. The spans of the diffs are shifted by offset lines.
+// When the last block is truncated, the ones before it are still added.
+func parseFence(patch *Patch, content, path string, offset int, cfg *parseConfig, opts []ParseOption) error {
+	hasBlocks := false
+	for line := range strings.Lines(content) {
+		line = strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(line, cfg.startSearch) || isFileDirective(line) {
+			hasBlocks = true
+			break
+		}
+	}
+	if !hasBlocks {
+		return nil
+	}
+	fenced, err := ParsePatch(content, opts...)
+	if err != nil {
+		shiftErrors(err, offset)
+		if !errors.Is(err, ErrTruncated) {
+			return err
+		}
+	}
+	for _, f := range fenced.Files {
+		header := f
+		header.Diffs = nil
+		if header.Op == FileModify && header.Path == "" {
+			header.Path = path
+			header.OldPath = path
+		}
+		i := patch.add(header)
+		for _, d := range f.Diffs {
+			d.Span.Start += offset
+			d.Span.End += offset
+			patch.Files[i].Diffs = append(patch.Files[i].Diffs, d)
+		}
+	}
+	return err
+}
+
+// isFileDirective reports whether line creates, deletes, or renames a file.
+func isFileDirective(line string) bool {
+	for _, prefix := range []string{newFilePrefix, deleteFilePrefix, renamePrefix} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// shiftErrors shifts the lines of the ParseErrors in err by offset.
+func shiftErrors(err error, offset int) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			shiftErrors(err, offset)
+		}
+		return
+	}
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		perr.Line += offset
+	}
+}
+
+// fenceMarker returns the run of backticks or tildes that opens a fence,
+// or "" if line does not start a fence.
+func fenceMarker(line string) string {
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		if n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// fencePath guesses the file name from a fence info string.
+// It accepts "main.go", "go main.go", "go:main.go", and "go title=main.go".
+func fencePath(info string) string {
+	var path string
+	for _, field := range strings.Fields(info) {
+		if _, value, ok := strings.Cut(field, "="); ok {
+			field = strings.Trim(value, `"'`)
+		} else if _, value, ok := strings.Cut(field, ":"); ok {
+			field = value
+		}
+		if strings.ContainsAny(field, "./") {
+			path = field
+		}
+	}
+	return path
+}
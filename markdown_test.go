@@ -0,0 +1,143 @@
+package fuzzypatch
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		patch Patch
+		err   bool
+	}{
+		{
+			name:  "no fences",
+			input: "Here is some text.\n",
+			patch: Patch{},
+		},
+		{
+			name:  "fence without a path",
+			input: "Here's the fix:\n\n```\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n```\n\nDone.\n",
+			patch: Patch{Files: []FileDiff{
+				{Diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}}},
+			}},
+		},
+		{
+			name: "fences keyed by path",
+			input: "```go main.go\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n```\n" +
+				"```go\nfunc example() {}\n```\n" +
+				"~~~~ lib/util.go\n<<<<<<< SEARCH line:2\n```\n=======\nqux\n>>>>>>> REPLACE\n~~~~\n",
+			patch: Patch{Files: []FileDiff{
+				{Path: "main.go", OldPath: "main.go", Diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}}},
+				{Path: "lib/util.go", OldPath: "lib/util.go", Diffs: []Diff{{Line: 2, Search: "```\n", Replace: "qux\n"}}},
+			}},
+		},
+		{
+			name:  "file header inside fence",
+			input: "```diff\nFILE: a.txt\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n```\n",
+			patch: Patch{Files: []FileDiff{
+				{Path: "a.txt", OldPath: "a.txt", Diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}}},
+			}},
+		},
+		{
+			name:  "fence in a list item",
+			input: "1. Update main.go:\n\n   ```\n   <<<<<<< SEARCH line:1\n   foo\n   =======\n   bar\n   >>>>>>> REPLACE\n   ```\n",
+			patch: Patch{Files: []FileDiff{
+				{Diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}}},
+			}},
+		},
+		{
+			name:  "fenced delete",
+			input: "Remove the old file:\n\n```\nDELETE FILE: old.txt\n```\n",
+			patch: Patch{Files: []FileDiff{
+				{OldPath: "old.txt", Op: FileDelete},
+			}},
+		},
+		{
+			name:  "fenced rename",
+			input: "```\nRENAME a.txt -> b.txt\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n```\n",
+			patch: Patch{Files: []FileDiff{
+				{Path: "b.txt", OldPath: "a.txt", Op: FileRename, Diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}}},
+			}},
+		},
+		{
+			name:  "invalid block",
+			input: "```\n<<<<<<< SEARCH line:1\nfoo\n```\n",
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := ParseMarkdown(tt.input)
+			if tt.err {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, patch, tt.patch, ignoreSpans)
+		})
+	}
+}
+
+func TestFencePath(t *testing.T) {
+	tests := []struct {
+		info string
+		path string
+	}{
+		{"", ""},
+		{"go", ""},
+		{"main.go", "main.go"},
+		{"go main.go", "main.go"},
+		{"go:cmd/main.go", "cmd/main.go"},
+		{`go title="main.go"`, "main.go"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, fencePath(tt.info), tt.path, tt.info)
+	}
+}
+
+func TestParseMarkdownAider(t *testing.T) {
+	input := "Change the greeting:\n\n" +
+		"main.py\n```python\n<<<<<<< SEARCH\nprint('hi')\n=======\nprint('hello')\n>>>>>>> REPLACE\n```\n" +
+		"```python\n<<<<<<< SEARCH\nx = 1\n=======\nx = 2\n>>>>>>> REPLACE\n```\n"
+	patch, err := ParseMarkdown(input, WithAider())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, patch, Patch{Files: []FileDiff{
+		{Path: "main.py", OldPath: "main.py", Diffs: []Diff{{Search: "print('hi')\n", Replace: "print('hello')\n"}}},
+		{Diffs: []Diff{{Search: "x = 1\n", Replace: "x = 2\n"}}},
+	}}, ignoreSpans)
+}
+
+func TestParseMarkdownUnclosed(t *testing.T) {
+	// the output was cut off after the last block
+	input := "```go main.go\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n"
+	patch, err := ParseMarkdown(input)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, patch, Patch{Files: []FileDiff{
+		{Path: "main.go", OldPath: "main.go", Diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}}},
+	}}, ignoreSpans)
+
+	// or in the middle of one
+	input = "```\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+		"```\n\n```\n<<<<<<< SEARCH line:5\nbaz\n=======\n"
+	patch, err = ParseMarkdown(input)
+	assert.ErrorIs(t, err, ErrTruncated)
+	var perr *ParseError
+	assert.Assert(t, errors.As(err, &perr))
+	assert.Equal(t, perr.Line, 13)
+	assert.DeepEqual(t, patch, Patch{Files: []FileDiff{
+		{Diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}}},
+	}}, ignoreSpans)
+}
+
+func TestParseMarkdownSpans(t *testing.T) {
+	input := "Intro\n\n```go main.go\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n```\n"
+	patch, err := ParseMarkdown(input)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, patch.Diffs("main.go")[0].Span, Span{Start: 4, End: 8})
+}
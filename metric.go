@@ -0,0 +1,257 @@
+package fuzzypatch
+
+import (
+	"math"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/agnivade/levenshtein"
+)
+
+// Metric scores the similarity of two strings between 0 and 1, where 1
+// means they're identical. Metrics must score identical strings as 1.
+type Metric func(a, b string) float64
+
+// WithMetric sets the metric used to compare candidates with the search text.
+func WithMetric(m Metric) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.metric = m
+	}
+}
+
+// Levenshtein is 1 - (levenshtein distance / maxLen), where the distance
+// and lengths are both counted in runes. It's the default metric.
+func Levenshtein(a, b string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1.0
+	}
+	dist := levenshtein.ComputeDistance(a, b)
+	return 1 - float64(dist)/float64(max(utf8.RuneCountInString(a), utf8.RuneCountInString(b)))
+}
+
+// TokenSimilarity is like Levenshtein, but it counts edits to whole tokens
+// instead of characters. Identifiers and numbers are tokens, every other
+// non-space character is a token of its own, and whitespace is ignored.
+// It's more forgiving of formatting changes, and faster on long text.
+func TokenSimilarity(a, b string) float64 {
+	x, y := tokens(a), tokens(b)
+	if len(x) == 0 && len(y) == 0 {
+		return 1.0
+	}
+	return 1 - float64(editDistance(x, y))/float64(max(len(x), len(y)))
+}
+
+// tokens splits s into the tokens compared by TokenSimilarity.
+func tokens(s string) []string {
+	var toks []string
+	isWord := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case isWord(r):
+			j := i + size
+			for j < len(s) {
+				r, size := utf8.DecodeRuneInString(s[j:])
+				if !isWord(r) {
+					break
+				}
+				j += size
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		default:
+			toks = append(toks, s[i:i+size])
+			i += size
+		}
+	}
+	return toks
+}
+
+// editDistance returns the levenshtein distance between two sequences.
+func editDistance[T comparable](a, b []T) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			prev, row[j] = row[j], min(row[j]+1, row[j-1]+1, prev+cost)
+		}
+	}
+	return row[len(b)]
+}
+
+// DamerauLevenshtein is like Levenshtein, but swapping two adjacent
+// characters counts as a single edit. It suits prose and identifiers, where
+// transposition typos are common.
+func DamerauLevenshtein(a, b string) float64 {
+	x, y := []rune(a), []rune(b)
+	if len(x) == 0 && len(y) == 0 {
+		return 1.0
+	}
+	// the optimal string alignment distance, using the last three rows
+	prev2 := make([]int, len(y)+1)
+	prev := make([]int, len(y)+1)
+	row := make([]int, len(y)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		row[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			row[j] = min(prev[j]+1, row[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && x[i-1] == y[j-2] && x[i-2] == y[j-1] {
+				row[j] = min(row[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, row = prev, row, prev2
+	}
+	return 1 - float64(prev[len(y)])/float64(max(len(x), len(y)))
+}
+
+// JaroWinkler is the Jaro-Winkler similarity, which rewards text that
+// shares a prefix. It's cheap and works well for short text such as
+// single-line config values, but it's a poor fit for long blocks, where
+// distant characters can't be matched.
+func JaroWinkler(a, b string) float64 {
+	x, y := []rune(a), []rune(b)
+	if len(x) == 0 && len(y) == 0 {
+		return 1.0
+	}
+	if len(x) == 0 || len(y) == 0 {
+		return 0
+	}
+	window := max(0, max(len(x), len(y))/2-1)
+	xMatched := make([]bool, len(x))
+	yMatched := make([]bool, len(y))
+	matches := 0
+	for i := range x {
+		for j := max(0, i-window); j < min(len(y), i+window+1); j++ {
+			if !yMatched[j] && x[i] == y[j] {
+				xMatched[i], yMatched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+	transpositions := 0
+	for i, j := 0, 0; i < len(x); i++ {
+		if !xMatched[i] {
+			continue
+		}
+		for !yMatched[j] {
+			j++
+		}
+		if x[i] != y[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	jaro := (m/float64(len(x)) + m/float64(len(y)) + (m-float64(transpositions/2))/m) / 3
+	prefix := 0
+	for prefix < min(4, len(x), len(y)) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// ngramSize is the length of the character n-grams compared by NGramCosine.
+const ngramSize = 3
+
+// NGramCosine is the cosine similarity of the character trigram counts of
+// the two strings. It ignores the order of the trigrams, so it tolerates
+// moved and reordered text, such as shuffled config keys, and it's fast on
+// long blocks. It's less precise than the edit distance metrics.
+func NGramCosine(a, b string) float64 {
+	if a == b {
+		return 1.0
+	}
+	x, y := ngrams(a), ngrams(b)
+	if len(x) == 0 || len(y) == 0 {
+		return 0
+	}
+	var dot, xNorm, yNorm float64
+	for g, n := range x {
+		dot += float64(n * y[g])
+		xNorm += float64(n * n)
+	}
+	for _, n := range y {
+		yNorm += float64(n * n)
+	}
+	return dot / math.Sqrt(xNorm*yNorm)
+}
+
+// ngrams counts the character n-grams in s. Text shorter than an n-gram is
+// a single n-gram.
+func ngrams(s string) map[string]int {
+	r := []rune(s)
+	counts := map[string]int{}
+	if len(r) > 0 && len(r) < ngramSize {
+		counts[s]++
+	}
+	for i := 0; i+ngramSize <= len(r); i++ {
+		counts[string(r[i:i+ngramSize])]++
+	}
+	return counts
+}
+
+// LineSimilarity compares the text line by line with Levenshtein, and
+// averages the scores of the lines. It's much faster than comparing the
+// whole text at once for long blocks.
+func LineSimilarity(a, b string) float64 {
+	return ByLine(Levenshtein)(a, b)
+}
+
+// ByLine returns a metric which scores each line with m and returns the
+// average. Text with the same number of lines is compared pairwise, and
+// otherwise the lines are aligned to maximize the total score, with
+// unaligned lines scoring 0.
+func ByLine(m Metric) Metric {
+	return func(a, b string) float64 {
+		x, y := trimSplit(a), trimSplit(b)
+		if len(x) == 0 && len(y) == 0 {
+			return 1.0
+		}
+		var total float64
+		if len(x) == len(y) {
+			for i := range x {
+				total += m(x[i], y[i])
+			}
+		} else {
+			total = alignLines(x, y, m)
+		}
+		return total / float64(max(len(x), len(y)))
+	}
+}
+
+// alignLines returns the highest total score of an in-order alignment of
+// the lines in x with the lines in y.
+func alignLines(x, y []string, m Metric) float64 {
+	prev := make([]float64, len(y)+1)
+	row := make([]float64, len(y)+1)
+	for i := 1; i <= len(x); i++ {
+		for j := 1; j <= len(y); j++ {
+			row[j] = max(prev[j], row[j-1], prev[j-1]+m(x[i-1], y[j-1]))
+		}
+		prev, row = row, prev
+	}
+	return prev[len(y)]
+}
@@ -0,0 +1,60 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric Metric
+		a, b   string
+		want   float64
+	}{
+		{name: "levenshtein empty", metric: Levenshtein, a: "", b: "", want: 1},
+		{name: "levenshtein identical", metric: Levenshtein, a: "hello", b: "hello", want: 1},
+		{name: "levenshtein substitution", metric: Levenshtein, a: "hello", b: "hallo", want: 0.8},
+		{name: "levenshtein multibyte", metric: Levenshtein, a: "héllo", b: "hello", want: 0.8},
+		{name: "levenshtein cjk", metric: Levenshtein, a: "日本語", b: "日本人", want: 1 - float64(1)/3},
+		{name: "levenshtein against empty", metric: Levenshtein, a: "abc", b: "", want: 0},
+		{name: "tokens empty", metric: TokenSimilarity, a: " \n", b: "", want: 1},
+		{name: "tokens ignore whitespace", metric: TokenSimilarity, a: "x  :=\tf(a, b)\n", b: "x := f(a,b)", want: 1},
+		{name: "tokens renamed identifier", metric: TokenSimilarity, a: "x := f(a, b)", b: "x := g(a, b)", want: 1 - float64(1)/9},
+		{name: "tokens different", metric: TokenSimilarity, a: "foo", b: "bar", want: 0},
+		{name: "damerau empty", metric: DamerauLevenshtein, a: "", b: "", want: 1},
+		{name: "damerau transposition", metric: DamerauLevenshtein, a: "hello", b: "hlelo", want: 0.8},
+		{name: "damerau substitution", metric: DamerauLevenshtein, a: "hello", b: "hallo", want: 0.8},
+		{name: "levenshtein transposition", metric: Levenshtein, a: "hello", b: "hlelo", want: 0.6},
+		{name: "jaro-winkler identical", metric: JaroWinkler, a: "martha", b: "martha", want: 1},
+		{name: "jaro-winkler", metric: JaroWinkler, a: "martha", b: "marhta", want: 0.9611111111111111},
+		{name: "jaro-winkler no matches", metric: JaroWinkler, a: "abc", b: "xyz", want: 0},
+		{name: "jaro-winkler against empty", metric: JaroWinkler, a: "abc", b: "", want: 0},
+		{name: "ngram identical", metric: NGramCosine, a: "abc", b: "abc", want: 1},
+		{name: "ngram reordered", metric: NGramCosine, a: "abcd", b: "bcda", want: 0.5},
+		{name: "ngram short", metric: NGramCosine, a: "ab", b: "abc", want: 0},
+		{name: "ngram against empty", metric: NGramCosine, a: "abc", b: "", want: 0},
+		{name: "lines identical", metric: LineSimilarity, a: "a\nb\n", b: "a\nb\n", want: 1},
+		{name: "lines pairwise", metric: LineSimilarity, a: "abcd\nxyz\n", b: "abce\nxyz\n", want: 0.9},
+		{name: "lines added", metric: LineSimilarity, a: "a\n\nb\n", b: "a\nb\n", want: float64(2) / 3},
+		{name: "lines against empty", metric: LineSimilarity, a: "a\n", b: "", want: 0},
+		{name: "by line with tokens", metric: ByLine(TokenSimilarity), a: "f( x )\ng()\n", b: "f(x)\ng()\n", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.metric(tt.a, tt.b), tt.want)
+		})
+	}
+}
+
+func TestWithMetric(t *testing.T) {
+	source := "func f() {\n\treturn   x+1\n}\n"
+	diff := Diff{Line: 2, Search: "return x + 1\n", Replace: "return x + 2\n"}
+	_, ok := Search(source, diff, WithThreshold(1))
+	assert.Assert(t, !ok)
+	edit, ok := Search(source, diff, WithThreshold(1), WithMetric(TokenSimilarity))
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, edit, Edit{Start: 11, End: 25, Text: "return x + 2\n"})
+}
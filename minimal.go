@@ -0,0 +1,140 @@
+package fuzzypatch
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxDiffCells limits the size of the table used to diff the words of an
+// edit, so huge regions are replaced as a whole instead.
+const maxDiffCells = 1 << 22
+
+// MinimizeEdit splits edit into the smallest edits that only replace the
+// words of source that the edit changes. Words are runs of letters and
+// digits, runs of whitespace, and single punctuation characters. Applying
+// the returned edits has the same result as applying edit, but unchanged
+// text in the region, and its formatting, is left untouched. It returns
+// no edits if the edit doesn't change anything.
+func MinimizeEdit(source string, edit Edit) []Edit {
+	a, b := words(source[edit.Start:edit.End]), words(edit.Text)
+
+	// skip the words the two sides start and end with
+	p := 0
+	for p < len(a) && p < len(b) && a[p] == b[p] {
+		p++
+	}
+	q := 0
+	for q < len(a)-p && q < len(b)-p && a[len(a)-1-q] == b[len(b)-1-q] {
+		q++
+	}
+	x, y := a[p:len(a)-q], b[p:len(b)-q]
+	pos := edit.Start + len(strings.Join(a[:p], ""))
+	if len(x) == 0 && len(y) == 0 {
+		return nil
+	}
+	if (len(x)+1)*(len(y)+1) > maxDiffCells {
+		old := strings.Join(x, "")
+		return []Edit{{Start: pos, End: pos + len(old), Text: strings.Join(y, "")}}
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var edits []Edit
+	var text strings.Builder
+	start := -1 // the start of the pending edit, if any
+	flush := func() {
+		if start >= 0 {
+			edits = append(edits, Edit{Start: start, End: pos, Text: text.String()})
+			start = -1
+			text.Reset()
+		}
+	}
+	for i, j := 0, 0; i < len(x) || j < len(y); {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			flush()
+			pos += len(x[i])
+			i, j = i+1, j+1
+		case j < len(y) && (i == len(x) || lcs[i][j+1] >= lcs[i+1][j]):
+			if start < 0 {
+				start = pos
+			}
+			text.WriteString(y[j])
+			j++
+		default:
+			if start < 0 {
+				start = pos
+			}
+			pos += len(x[i])
+			i++
+		}
+	}
+	flush()
+	return edits
+}
+
+// TrimEdit shrinks edit to leave out the lines it starts and ends with that
+// are already the same in source, so that it only covers the lines that
+// change. Edits that don't change anything are trimmed to an empty insertion.
+func TrimEdit(source string, edit Edit) Edit {
+	a, b := trimSplit(source[edit.Start:edit.End]), trimSplit(edit.Text)
+	p := 0
+	for p < len(a) && p < len(b) && a[p] == b[p] {
+		edit.Start += len(a[p])
+		p++
+	}
+	q := 0
+	for q < len(a)-p && q < len(b)-p && a[len(a)-1-q] == b[len(b)-1-q] {
+		edit.End -= len(a[len(a)-1-q])
+		q++
+	}
+	edit.End = max(edit.Start, edit.End)
+	edit.Text = strings.Join(b[p:len(b)-q], "")
+	return edit
+}
+
+// words splits s into runs of letters and digits, runs of whitespace, and
+// single characters of everything else. Joining the words gives back s.
+func words(s string) []string {
+	class := func(r rune) int {
+		switch {
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		default:
+			return 0
+		}
+	}
+	var words []string
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		j := i + size
+		if c := class(r); c != 0 {
+			for j < len(s) {
+				r, size := utf8.DecodeRuneInString(s[j:])
+				if class(r) != c {
+					break
+				}
+				j += size
+			}
+		}
+		words = append(words, s[i:j])
+		i = j
+	}
+	return words
+}
@@ -0,0 +1,108 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestMinimizeEdit(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		edit   Edit
+		want   []Edit
+	}{
+		{
+			name:   "single word",
+			source: "func f(a int) int {\n\treturn a + 1\n}\n",
+			edit:   Edit{Start: 0, End: 36, Text: "func f(a int) int {\n\treturn a + 2\n}\n"},
+			want:   []Edit{{Start: 32, End: 33, Text: "2"}},
+		},
+		{
+			name:   "several changes",
+			source: "x := foo(1, 2)\n",
+			edit:   Edit{Start: 0, End: 15, Text: "y := foo(1, 3)\n"},
+			want:   []Edit{{Start: 0, End: 1, Text: "y"}, {Start: 12, End: 13, Text: "3"}},
+		},
+		{
+			name:   "insertion and deletion",
+			source: "prefix a b c d suffix",
+			edit:   Edit{Start: 7, End: 14, Text: "a b x c"},
+			want:   []Edit{{Start: 11, End: 11, Text: "x "}, {Start: 12, End: 14, Text: ""}},
+		},
+		{
+			name:   "unchanged",
+			source: "same\n",
+			edit:   Edit{Start: 0, End: 5, Text: "same\n"},
+			want:   nil,
+		},
+		{
+			name:   "insertion into empty region",
+			source: "ab",
+			edit:   Edit{Start: 1, End: 1, Text: "x"},
+			want:   []Edit{{Start: 1, End: 1, Text: "x"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edits := MinimizeEdit(tt.source, tt.edit)
+			want, err := Apply(tt.source, []Edit{tt.edit})
+			assert.NilError(t, err)
+			got, err := Apply(tt.source, edits)
+			assert.NilError(t, err)
+			assert.Equal(t, got, want)
+			assert.DeepEqual(t, edits, tt.want)
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	assert.DeepEqual(t, words("a_1  (x)\n"), []string{"a_1", "  ", "(", "x", ")", "\n"})
+}
+
+func TestTrimEdit(t *testing.T) {
+	source := "func f() {\n\ta()\n\tb()\n}\n"
+	tests := []struct {
+		name string
+		edit Edit
+		want Edit
+	}{
+		{
+			name: "boundary lines",
+			edit: Edit{Start: 0, End: 23, Text: "func f() {\n\ta()\n\tc()\n}\n"},
+			want: Edit{Start: 16, End: 21, Text: "\tc()\n"},
+		},
+		{
+			name: "added lines",
+			edit: Edit{Start: 0, End: 23, Text: "func f() {\n\ta()\n\tx()\n\tb()\n}\n"},
+			want: Edit{Start: 16, End: 16, Text: "\tx()\n"},
+		},
+		{
+			name: "removed lines",
+			edit: Edit{Start: 0, End: 23, Text: "func f() {\n}\n"},
+			want: Edit{Start: 11, End: 21, Text: ""},
+		},
+		{
+			name: "unchanged",
+			edit: Edit{Start: 0, End: 23, Text: source},
+			want: Edit{Start: 23, End: 23, Text: ""},
+		},
+		{
+			name: "nothing in common",
+			edit: Edit{Start: 11, End: 16, Text: "\tz()\n"},
+			want: Edit{Start: 11, End: 16, Text: "\tz()\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edit := TrimEdit(source, tt.edit)
+			assert.DeepEqual(t, edit, tt.want)
+			want, err := Apply(source, []Edit{tt.edit})
+			assert.NilError(t, err)
+			got, err := Apply(source, []Edit{edit})
+			assert.NilError(t, err)
+			assert.Equal(t, got, want)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package fuzzypatch
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// NormalizeEdits returns the edits sorted by offset, with duplicates removed,
+// adjacent edits merged into one, and insertions of empty text dropped.
+// Insertions at the same offset are kept in the order given. It returns an
+// error for an invalid range, or for edits that overlap without being the
+// same. The edits aren't modified.
+func NormalizeEdits(edits []Edit) ([]Edit, error) {
+	sorted := slices.Clone(edits)
+	slices.SortStableFunc(sorted, func(a, b Edit) int {
+		return cmp.Or(a.Start-b.Start, a.End-b.End)
+	})
+	var out []Edit
+	var prev Edit // the last edit that was kept, before it was merged
+	for _, e := range sorted {
+		if e.Start < 0 || e.End < e.Start {
+			return nil, fmt.Errorf("invalid edit range [%d,%d)", e.Start, e.End)
+		}
+		if e.Start == e.End && e.Text == "" {
+			continue
+		}
+		if len(out) == 0 {
+			out, prev = append(out, e), e
+			continue
+		}
+		if e == prev {
+			continue
+		}
+		prev = e
+		last := &out[len(out)-1]
+		switch {
+		case e.Start < last.End:
+			return nil, fmt.Errorf("%w at [%d,%d)", ErrOverlap, e.Start, e.End)
+		case e.Start == last.End:
+			last.End = e.End
+			last.Text += e.Text
+		default:
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
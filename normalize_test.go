@@ -0,0 +1,82 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestNormalizeEdits(t *testing.T) {
+	tests := []struct {
+		name  string
+		edits []Edit
+		want  []Edit
+		err   string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:  "sorted",
+			edits: []Edit{{Start: 6, End: 7, Text: "z"}, {Start: 0, End: 1, Text: "x"}},
+			want:  []Edit{{Start: 0, End: 1, Text: "x"}, {Start: 6, End: 7, Text: "z"}},
+		},
+		{
+			name:  "no-ops",
+			edits: []Edit{{Start: 3, End: 3}, {Start: 0, End: 1, Text: "x"}},
+			want:  []Edit{{Start: 0, End: 1, Text: "x"}},
+		},
+		{
+			name:  "duplicates",
+			edits: []Edit{{Start: 0, End: 2, Text: "x"}, {Start: 0, End: 2, Text: "x"}},
+			want:  []Edit{{Start: 0, End: 2, Text: "x"}},
+		},
+		{
+			name:  "duplicates of a merged edit",
+			edits: []Edit{{Start: 0, End: 2, Text: "a"}, {Start: 2, End: 4, Text: "b"}, {Start: 2, End: 4, Text: "b"}},
+			want:  []Edit{{Start: 0, End: 4, Text: "ab"}},
+		},
+		{
+			name:  "adjacent",
+			edits: []Edit{{Start: 2, End: 4, Text: "b"}, {Start: 0, End: 2, Text: "a"}, {Start: 4, End: 4, Text: "c"}},
+			want:  []Edit{{Start: 0, End: 4, Text: "abc"}},
+		},
+		{
+			name:  "insertions",
+			edits: []Edit{{Start: 1, End: 1, Text: "b"}, {Start: 1, End: 3, Text: "X"}, {Start: 1, End: 1, Text: "c"}},
+			want:  []Edit{{Start: 1, End: 3, Text: "bcX"}},
+		},
+		{
+			name:  "overlap",
+			edits: []Edit{{Start: 0, End: 3, Text: "x"}, {Start: 2, End: 4, Text: "y"}},
+			err:   "overlapping edits at [2,4)",
+		},
+		{
+			name:  "invalid range",
+			edits: []Edit{{Start: 3, End: 1}},
+			err:   "invalid edit range [3,1)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeEdits(tt.edits)
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestNormalizeEditsApply(t *testing.T) {
+	source := "abcdef"
+	edits := []Edit{{Start: 4, End: 6, Text: "EF"}, {Start: 2, End: 4, Text: "CD"}, {Start: 2, End: 4, Text: "CD"}, {Start: 0, End: 0}}
+	normalized, err := NormalizeEdits(edits)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, normalized, []Edit{{Start: 2, End: 6, Text: "CDEF"}})
+	got, err := Apply(source, normalized)
+	assert.NilError(t, err)
+	assert.Equal(t, got, "abCDEF")
+}
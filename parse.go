@@ -1,40 +1,44 @@
 package fuzzypatch
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"iter"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
-type tokenType int
+// TokenType is the kind of a line in a patch.
+type TokenType int
 
 const (
-	startSearchType   tokenType = iota // "<<<<<<< SEARCH line:n
-	textSeparatorType                  // "======="
-	endReplaceType                     // ">>>>>>> REPLACE
-	textType                           // any other line (incl. blank)
-	invalidType
+	InvalidType       TokenType = iota // past the end of input, or not applicable
+	StartSearchType                    // "<<<<<<< SEARCH line:n
+	TextSeparatorType                  // "======="
+	EndReplaceType                     // ">>>>>>> REPLACE
+	EndDeleteType                      // ">>>>>>> DELETE
+	TextType                           // any other line (incl. blank)
 	EOF
 )
 
-const (
-	startSearchPrefix = "<<<<<<< SEARCH"
-	textSeparator     = "======="
-	endReplace        = ">>>>>>> REPLACE"
-)
-
-func tokenTypeString(typ tokenType) string {
+func (typ TokenType) String() string {
 	switch typ {
-	case startSearchType:
-		return "StartSearchType: " + startSearchPrefix + " line:n"
-	case textSeparatorType:
-		return "TextSeparatorType: " + textSeparator
-	case endReplaceType:
-		return "EndReplaceType: " + endReplace
-	case textType:
+	case StartSearchType:
+		return "StartSearchType"
+	case TextSeparatorType:
+		return "TextSeparatorType"
+	case EndReplaceType:
+		return "EndReplaceType"
+	case EndDeleteType:
+		return "EndDeleteType"
+	case TextType:
 		return "TextType"
-	case invalidType:
+	case InvalidType:
 		return "InvalidType"
 	case EOF:
 		return "EOF"
@@ -43,122 +47,593 @@ func tokenTypeString(typ tokenType) string {
 	}
 }
 
+// ParseError describes a syntax error in a patch.
+type ParseError struct {
+	Line     int       // 1-based line number of the offending text
+	Expected TokenType // The kind of line that was expected, InvalidType if Msg is set
+	Got      TokenType // The kind of line that was found
+	Text     string    // The offending text
+	Msg      string    // Description of the problem, if it isn't an unexpected line
+	Block    int       // 1-based index of the block containing the error, 0 if unknown
+	Err      error     // Underlying cause, if any
+}
+
+func (e *ParseError) Error() string {
+	msg := e.Msg
+	if msg == "" {
+		msg = fmt.Sprintf("expected %s, got %s", e.Expected, e.Got)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Block > 0 {
+		return fmt.Sprintf("%s: %q (block %d, line %d)", msg, e.Text, e.Block, e.Line)
+	}
+	return fmt.Sprintf("%s: %q (line %d)", msg, e.Text, e.Line)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ErrTruncated is the cause of the ParseError returned when the input ends
+// in the middle of a block, such as when a model's output was cut off.
+// The diffs before the incomplete block are returned along with it.
+var ErrTruncated = errors.New("truncated block")
+
+const (
+	startSearchPrefix = "<<<<<<< SEARCH"
+	textSeparator     = "======="
+	endReplace        = ">>>>>>> REPLACE"
+	endDelete         = ">>>>>>> DELETE"
+	fileHeaderPrefix  = "FILE:"
+	commentPrefix     = "#"
+	newFilePrefix     = "NEW FILE:"
+	deleteFilePrefix  = "DELETE FILE:"
+	renamePrefix      = "RENAME "
+	versionPrefix     = "FUZZYPATCH "
+)
+
+// ParseOption configures how patch text is parsed.
+type ParseOption func(*parseConfig)
+
+type parseConfig struct {
+	startSearch string
+	separator   string
+	endReplace  string
+	aider       bool
+	comments    bool
+	allErrors   bool
+	split       bufio.SplitFunc
+	version     int // syntax version selected by the patch's version header
+}
+
+// currentVersion is the latest version of the patch syntax, which is used
+// when a patch doesn't have a version header.
+const currentVersion = 2
+
+func newParseConfig(opts []ParseOption) *parseConfig {
+	cfg := &parseConfig{
+		startSearch: startSearchPrefix,
+		separator:   textSeparator,
+		endReplace:  endReplace,
+		version:     currentVersion,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithMarkers overrides the markers used to delimit blocks.
+// The search marker is matched as a prefix and may be followed by a line hint,
+// the separator and replace markers must match the whole line.
+// Empty strings leave the corresponding default in place.
+func WithMarkers(search, separator, replace string) ParseOption {
+	return func(cfg *parseConfig) {
+		if search != "" {
+			cfg.startSearch = search
+		}
+		if separator != "" {
+			cfg.separator = separator
+		}
+		if replace != "" {
+			cfg.endReplace = replace
+		}
+	}
+}
+
+// WithAider enables compatibility with Aider's SEARCH/REPLACE format, where
+// each block is immediately preceded by the path of the file it targets:
+//
+//	path/to/file
+//	<<<<<<< SEARCH
+//	...
+//	>>>>>>> REPLACE
+//
+// The paths are recognized by ParsePatch, and by ParseMarkdown on the line
+// before a fence.
+func WithAider() ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.aider = true
+	}
+}
+
+// WithComments attaches the "#" comment lines preceding each block to the
+// resulting Diff's Comment field. Comments are skipped when it's not set.
+func WithComments() ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.comments = true
+	}
+}
+
+// WithAllErrors makes Parse and ParsePatch keep going after a malformed block
+// and report every error, joined with errors.Join, instead of only the first.
+// Each error is a *ParseError tagged with the block it occurred in.
+func WithAllErrors() ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.allErrors = true
+	}
+}
+
+// isMarker reports whether line would be tokenized as a marker.
+func (cfg *parseConfig) isMarker(line string) bool {
+	return cfg.classify(line) != TextType
+}
+
+// needsEscape reports whether line must be escaped to be used as text.
+// Marker lines are escaped with a leading backslash, and so are lines that
+// would otherwise be mistaken for escaped markers. Other lines starting with
+// a backslash are left alone.
+func (cfg *parseConfig) needsEscape(line string) bool {
+	for {
+		if cfg.isMarker(line) {
+			return true
+		}
+		rest, ok := strings.CutPrefix(line, `\`)
+		if !ok {
+			return false
+		}
+		line = rest
+	}
+}
+
+func (cfg *parseConfig) escape(line string) string {
+	if cfg.needsEscape(line) {
+		return `\` + line
+	}
+	return line
+}
+
+func (cfg *parseConfig) unescape(line string) string {
+	if cfg.version < 2 {
+		return line
+	}
+	if rest, ok := strings.CutPrefix(line, `\`); ok && cfg.needsEscape(rest) {
+		return rest
+	}
+	return line
+}
+
 type token struct {
-	Type tokenType
+	Type TokenType
 	Line int
 	Text string
 }
 
-func tokenize(input string) iter.Seq[token] {
+// errorf returns a ParseError for a problem with the token.
+func (tok token) errorf(format string, args ...any) *ParseError {
+	return &ParseError{
+		Line: tok.Line + 1,
+		Got:  tok.Type,
+		Text: tok.Text,
+		Msg:  fmt.Sprintf(format, args...),
+	}
+}
+
+// wrapf is like errorf, but it also records the underlying cause.
+func (tok token) wrapf(err error, format string, args ...any) *ParseError {
+	e := tok.errorf(format, args...)
+	e.Err = err
+	return e
+}
+
+// tokenize splits lines into tokens. Blocks may be uniformly indented, in
+// which case the start marker's indentation is stripped from every line of
+// the block, and the rest of the block's markers must have the same indent.
+func tokenize(lines iter.Seq[string], cfg *parseConfig) iter.Seq[token] {
 	return func(yield func(token) bool) {
 		lineNo := 0
-		for line := range strings.Lines(input) {
-			trim := strings.TrimRight(line, "\r\n")
-			switch {
-			case strings.HasPrefix(line, startSearchPrefix):
-				if !yield(token{startSearchType, lineNo, line}) {
-					return
-				}
-			case trim == textSeparator:
-				if !yield(token{textSeparatorType, lineNo, line}) {
-					return
-				}
-			case trim == endReplace:
-				if !yield(token{endReplaceType, lineNo, line}) {
-					return
-				}
-			default:
-				if !yield(token{textType, lineNo, line}) {
-					return
-				}
+		inBlock := false
+		indent := "" // indentation of the current block
+		for line := range lines {
+			if lineNo == 0 {
+				line = strings.TrimPrefix(line, bom)
+			}
+			if inBlock {
+				line, _ = strings.CutPrefix(line, indent)
+			}
+			typ := cfg.classify(line)
+			if !inBlock && typ == TextType && cfg.classify(strings.TrimLeft(line, " \t")) == StartSearchType {
+				typ = StartSearchType
+				indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+				line = line[len(indent):]
+			}
+			switch typ {
+			case StartSearchType:
+				inBlock = true
+			case EndReplaceType, EndDeleteType:
+				inBlock = false
+				indent = ""
+			}
+			if !yield(token{typ, lineNo, line}) {
+				return
 			}
 			lineNo++
 		}
-		yield(token{EOF, 0, ""})
+		yield(token{EOF, lineNo, ""})
+	}
+}
+
+// classify returns the type of token that an unindented line would be.
+func (cfg *parseConfig) classify(line string) TokenType {
+	trim := strings.TrimRight(line, lineBreaks)
+	switch {
+	case strings.HasPrefix(line, cfg.startSearch):
+		return StartSearchType
+	case trim == cfg.separator:
+		return TextSeparatorType
+	case trim == cfg.endReplace:
+		return EndReplaceType
+	case trim == endDelete && cfg.version >= 2:
+		return EndDeleteType
+	default:
+		return TextType
 	}
 }
 
 type parser struct {
-	current token
-	next    func() (token, bool)
+	cfg       *parseConfig
+	lookahead []token // tokens pulled from next but not yet consumed
+	next      func() (token, bool)
+	comments  []string // comment lines since the last block
+	blocks    int      // number of blocks started
 }
 
-func (p *parser) read() token {
-	current := p.current
-	tok, ok := p.next()
-	if !ok {
-		p.current = token{Type: invalidType}
-	} else {
-		p.current = tok
+func newParser(input string, opts []ParseOption) (*parser, func()) {
+	if split := newParseConfig(opts).split; split != nil {
+		return newLinesParser(slices.Values(splitLines(input, split)), opts)
 	}
-	return current
+	return newLinesParser(strings.Lines(input), opts)
 }
 
-func (p *parser) expect(typ tokenType) (token, error) {
-	tok := p.read()
+func newLinesParser(lines iter.Seq[string], opts []ParseOption) (*parser, func()) {
+	cfg := newParseConfig(opts)
+	next, stop := iter.Pull(tokenize(lines, cfg))
+	return &parser{cfg: cfg, next: next}, stop
+}
+
+// peekAt returns the i'th unconsumed token. Tokens are pulled lazily so
+// that a block can be returned as soon as its end marker has been read.
+func (p *parser) peekAt(i int) token {
+	for len(p.lookahead) <= i {
+		tok, ok := p.next()
+		if !ok {
+			tok = token{Type: InvalidType}
+		}
+		p.lookahead = append(p.lookahead, tok)
+	}
+	return p.lookahead[i]
+}
+
+func (p *parser) peek() token {
+	return p.peekAt(0)
+}
+
+func (p *parser) read() token {
+	tok := p.peek()
+	p.lookahead = p.lookahead[1:]
+	return tok
+}
+
+// expect consumes the current token if it has the given type.
+// Mismatched tokens are left in place so the parser can recover.
+func (p *parser) expect(typ TokenType) (token, error) {
+	tok := p.peek()
 	if tok.Type != typ {
-		return token{}, fmt.Errorf("expected %s, got %s: %q (line %d))",
-			tokenTypeString(typ),
-			tokenTypeString(tok.Type),
-			tok.Text,
-			tok.Line,
-		)
+		err := &ParseError{
+			Line:     tok.Line + 1,
+			Expected: typ,
+			Got:      tok.Type,
+			Text:     tok.Text,
+		}
+		if tok.Type == EOF {
+			err.Err = ErrTruncated
+		}
+		return token{}, err
 	}
-	return tok, nil
+	return p.read(), nil
 }
 
-func (p *parser) parseStartSearch() (int, error) {
-	for p.current.Type == textType && strings.TrimSpace(p.current.Text) == "" {
+// resync skips tokens until the start of the next block.
+func (p *parser) resync() {
+	for p.peek().Type != StartSearchType && p.peek().Type != EOF && p.peek().Type != InvalidType {
 		p.read()
 	}
-	tok, err := p.expect(startSearchType)
+}
+
+// skipBlank skips blank lines and comments. The comment text is collected
+// so it can be attached to the next block.
+func (p *parser) skipBlank() {
+	for p.peek().Type == TextType {
+		text := strings.TrimSpace(p.peek().Text)
+		if comment, ok := strings.CutPrefix(text, commentPrefix); ok {
+			p.comments = append(p.comments, strings.TrimPrefix(comment, " "))
+		} else if text != "" {
+			return
+		}
+		p.read()
+	}
+}
+
+// parseFileHeader consumes a file header line if there is one:
+//
+//	FILE: path
+//	NEW FILE: path
+//	DELETE FILE: path
+//	RENAME old -> new
+//
+// In Aider mode, a line immediately followed by a start marker is a path too.
+func (p *parser) parseFileHeader() (FileDiff, bool, error) {
+	tok := p.peek()
+	if tok.Type != TextType {
+		return FileDiff{}, false, nil
+	}
+	text := strings.TrimSpace(tok.Text)
+	var header FileDiff
+	if path, ok := strings.CutPrefix(text, fileHeaderPrefix); ok {
+		path = strings.TrimSpace(path)
+		header = FileDiff{Path: path, OldPath: path}
+	} else if path, ok := strings.CutPrefix(text, newFilePrefix); ok {
+		header = FileDiff{Path: strings.TrimSpace(path), Op: FileCreate}
+	} else if path, ok := strings.CutPrefix(text, deleteFilePrefix); ok {
+		header = FileDiff{OldPath: strings.TrimSpace(path), Op: FileDelete}
+	} else if paths, ok := strings.CutPrefix(text, renamePrefix); ok {
+		oldPath, newPath, ok := strings.Cut(paths, " -> ")
+		if !ok {
+			p.read()
+			return FileDiff{}, false, tok.errorf("expected %q", renamePrefix+"old -> new")
+		}
+		header = FileDiff{Path: strings.TrimSpace(newPath), OldPath: strings.TrimSpace(oldPath), Op: FileRename}
+	} else if p.cfg.aider && p.peekAt(1).Type == StartSearchType {
+		header = FileDiff{Path: text, OldPath: text}
+	} else {
+		return FileDiff{}, false, nil
+	}
+	p.read()
+	return header, true, nil
+}
+
+// parseVersion consumes the version header if there is one, and switches
+// to the syntax rules of that version:
+//
+//	FUZZYPATCH v2
+//
+// The header must come before anything other than blank lines and comments.
+// Version 1 is the original syntax, which only has plain line hints, and
+// doesn't have DELETE blocks or escaped markers.
+func (p *parser) parseVersion() error {
+	p.skipBlank()
+	tok := p.peek()
+	version, ok := strings.CutPrefix(strings.TrimSpace(tok.Text), versionPrefix)
+	if tok.Type != TextType || !ok {
+		return nil
+	}
+	p.read()
+	switch strings.TrimSpace(version) {
+	case "v1":
+		p.cfg.version = 1
+	case "v2":
+		p.cfg.version = 2
+	default:
+		return tok.errorf("unsupported patch version %q", strings.TrimSpace(version))
+	}
+	return nil
+}
+
+// parseStartSearch parses the start marker and its attributes into a Diff.
+// The attributes are space separated key:value pairs, all of which are optional.
+func (p *parser) parseStartSearch() (Diff, error) {
+	p.skipBlank()
+	tok, err := p.expect(StartSearchType)
 	if err != nil {
-		return 0, err
+		return Diff{}, err
 	}
-	suffix, _ := strings.CutPrefix(tok.Text, startSearchPrefix)
-	lineStr, ok := strings.CutPrefix(strings.TrimSpace(suffix), "line:")
-	if !ok {
-		return 0, fmt.Errorf("expected %s, got %q", tokenTypeString(startSearchType), tok.Text)
+	suffix, _ := strings.CutPrefix(tok.Text, p.cfg.startSearch)
+	diff := Diff{Span: Span{Start: tok.Line + 1}}
+	if p.cfg.comments {
+		diff.Comment = strings.Join(p.comments, "\n")
 	}
-	line, err := strconv.Atoi(lineStr)
+	p.comments = nil
+	attrs, err := splitAttributes(suffix)
 	if err != nil {
-		return 0, fmt.Errorf("expected %s, got %q: %w", tokenTypeString(startSearchType), tok.Text, err)
+		return Diff{}, tok.wrapf(err, "invalid attributes %q", strings.TrimSpace(suffix))
+	}
+	for _, attr := range attrs {
+		key, value, ok := strings.Cut(attr, ":")
+		if !ok {
+			return Diff{}, tok.errorf("invalid attribute %q", attr)
+		}
+		if p.cfg.version < 2 && key != "line" {
+			return Diff{}, tok.errorf("unknown attribute %q", key)
+		}
+		switch key {
+		case "line":
+			if p.cfg.version < 2 {
+				// version 1 only has plain line numbers
+				diff.Line, err = strconv.Atoi(value)
+				if err != nil || diff.Line < 0 {
+					return Diff{}, tok.errorf("invalid line hint %q", value)
+				}
+				continue
+			}
+			if value == "$" {
+				diff.Line = EndOfFile
+				continue
+			}
+			start, end, isRange := strings.Cut(value, "-")
+			diff.Line, err = strconv.Atoi(start)
+			if err != nil {
+				return Diff{}, tok.wrapf(err, "invalid line hint %q", value)
+			}
+			if isRange {
+				diff.LineEnd, err = strconv.Atoi(end)
+				if err != nil || diff.LineEnd < diff.Line {
+					return Diff{}, tok.errorf("invalid line range %q", value)
+				}
+			}
+			if diff.Line < 0 {
+				return Diff{}, tok.errorf("invalid line hint %q", value)
+			}
+		case "occurrence":
+			diff.Occurrence, err = strconv.Atoi(value)
+			if err != nil || diff.Occurrence < 1 {
+				return Diff{}, tok.errorf("invalid occurrence %q", value)
+			}
+		case "fingerprint":
+			diff.Fingerprint = value
+		case "column":
+			diff.Column, err = strconv.Atoi(value)
+			if err != nil || diff.Column < 1 {
+				return Diff{}, tok.errorf("invalid column %q", value)
+			}
+		case "context":
+			before, after, ok := strings.Cut(value, ",")
+			if !ok {
+				after = before
+			}
+			diff.ContextBefore, err = strconv.Atoi(before)
+			if err == nil {
+				diff.ContextAfter, err = strconv.Atoi(after)
+			}
+			if err != nil || diff.ContextBefore < 0 || diff.ContextAfter < 0 {
+				return Diff{}, tok.errorf("invalid context %q", value)
+			}
+		case "regex":
+			diff.Regex, err = strconv.ParseBool(value)
+			if err != nil {
+				return Diff{}, tok.errorf("invalid regex flag %q", value)
+			}
+		case "reject":
+			text := value
+			if strings.HasPrefix(value, `"`) {
+				text, err = strconv.Unquote(value)
+			}
+			if err != nil || text == "" {
+				return Diff{}, tok.errorf("invalid reject text %q", value)
+			}
+			diff.Reject = append(diff.Reject, text)
+		case "reject_radius":
+			diff.RejectRadius, err = strconv.Atoi(value)
+			if err != nil || diff.RejectRadius < 0 {
+				return Diff{}, tok.errorf("invalid reject radius %q", value)
+			}
+		case "threshold":
+			diff.Threshold, err = strconv.ParseFloat(value, 64)
+			if err != nil || diff.Threshold <= 0 || diff.Threshold > 1 {
+				return Diff{}, tok.errorf("invalid threshold %q", value)
+			}
+		default:
+			return Diff{}, tok.errorf("unknown attribute %q", key)
+		}
+	}
+	return diff, nil
+}
+
+// splitAttributes splits the attributes of a start marker on whitespace,
+// keeping double-quoted values, such as reject:"if err != nil", together.
+func splitAttributes(s string) ([]string, error) {
+	var attrs []string
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			return attrs, nil
+		}
+		n := strings.IndexFunc(s, func(r rune) bool { return r == '"' || unicode.IsSpace(r) })
+		if n < 0 {
+			n = len(s)
+		} else if s[n] == '"' {
+			quoted, err := strconv.QuotedPrefix(s[n:])
+			if err != nil {
+				return nil, err
+			}
+			n += len(quoted)
+		}
+		attrs = append(attrs, s[:n])
+		s = s[n:]
 	}
-	return line, nil
 }
 
+// parseDiff parses the next block, and tags any error with its index.
 func (p *parser) parseDiff() (Diff, error) {
-	var diff Diff
-	var err error
-	diff.Line, err = p.parseStartSearch()
+	p.blocks++
+	diff, err := p.parseBlock()
 	if err != nil {
+		var perr *ParseError
+		if errors.As(err, &perr) {
+			perr.Block = p.blocks
+		}
 		return Diff{}, err
 	}
-	for p.current.Type == textType {
-		diff.Search += p.current.Text
+	return diff, nil
+}
+
+func (p *parser) parseBlock() (Diff, error) {
+	diff, err := p.parseStartSearch()
+	if err != nil {
+		return Diff{}, err
+	}
+	for p.peek().Type == TextType {
+		diff.Search += p.cfg.unescape(p.peek().Text)
 		p.read()
 	}
-	if _, err := p.expect(textSeparatorType); err != nil {
+	// a block without a replace section deletes the search text
+	if p.peek().Type == EndDeleteType {
+		diff.Span.End = p.read().Line + 1
+		return diff, nil
+	}
+	if _, err := p.expect(TextSeparatorType); err != nil {
 		return Diff{}, err
 	}
-	for p.current.Type == textType {
-		diff.Replace += p.current.Text
+	for p.peek().Type == TextType {
+		diff.Replace += p.cfg.unescape(p.peek().Text)
 		p.read()
 	}
-	if _, err := p.expect(endReplaceType); err != nil {
+	end, err := p.expect(EndReplaceType)
+	if err != nil {
 		return Diff{}, err
 	}
+	diff.Span.End = end.Line + 1
 	return diff, nil
 }
 
-func Parse(input string) ([]Diff, error) {
-	next, stop := iter.Pull(tokenize(input))
-	defer stop()
-	p := parser{next: next}
-	p.read()
+// Parse parses all the blocks in input.
+func Parse(input string, opts ...ParseOption) ([]Diff, error) {
+	if newParseConfig(opts).allErrors {
+		diffs, errs := ParseLenient(input, opts...)
+		if err := errors.Join(errs...); err != nil {
+			if errors.Is(err, ErrTruncated) {
+				return diffs, err
+			}
+			return nil, err
+		}
+		return diffs, nil
+	}
 	var diffs []Diff
-	for p.current.Type != EOF {
-		diff, err := p.parseDiff()
+	for diff, err := range ParseSeq(input, opts...) {
+		if errors.Is(err, ErrTruncated) {
+			return diffs, err
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -166,3 +641,180 @@ func Parse(input string) ([]Diff, error) {
 	}
 	return diffs, nil
 }
+
+// ParseSeq returns an iterator over the blocks in input. Blocks are parsed
+// lazily, so breaking out of the loop stops parsing the rest of the input.
+// Iteration stops after the first error.
+func ParseSeq(input string, opts ...ParseOption) iter.Seq2[Diff, error] {
+	return func(yield func(Diff, error) bool) {
+		p, stop := newParser(input, opts)
+		defer stop()
+		p.parseDiffs(yield)
+	}
+}
+
+func (p *parser) parseDiffs(yield func(Diff, error) bool) {
+	if err := p.parseVersion(); err != nil {
+		yield(Diff{}, err)
+		return
+	}
+	for p.skipBlank(); p.peek().Type != EOF; p.skipBlank() {
+		diff, err := p.parseDiff()
+		if err != nil {
+			yield(Diff{}, err)
+			return
+		}
+		if !yield(diff, nil) {
+			return
+		}
+	}
+}
+
+// ParseLenient is like Parse, but malformed blocks are skipped instead of
+// failing the whole patch. After an error the parser resynchronizes on the
+// next start marker. It returns the diffs that parsed successfully along
+// with one error for each block that was skipped.
+func ParseLenient(input string, opts ...ParseOption) ([]Diff, []error) {
+	p, stop := newParser(input, opts)
+	defer stop()
+	var diffs []Diff
+	var errs []error
+	if err := p.parseVersion(); err != nil {
+		return nil, []error{err}
+	}
+	for p.skipBlank(); p.peek().Type != EOF; p.skipBlank() {
+		diff, err := p.parseDiff()
+		if err != nil {
+			errs = append(errs, err)
+			p.resync()
+			continue
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, errs
+}
+
+// ParseReader parses blocks from r incrementally. Each diff is yielded as
+// soon as its end marker has been read, so streamed input can be processed
+// before it's complete. Iteration stops after the first error.
+func ParseReader(r io.Reader, opts ...ParseOption) iter.Seq2[Diff, error] {
+	return func(yield func(Diff, error) bool) {
+		var readErr error
+		lines := func(yield func(string) bool) {
+			if split := newParseConfig(opts).split; split != nil {
+				sc := bufio.NewScanner(r)
+				sc.Buffer(nil, math.MaxInt)
+				sc.Split(split)
+				for sc.Scan() {
+					if !yield(sc.Text()) {
+						return
+					}
+				}
+				readErr = sc.Err()
+				return
+			}
+			br := bufio.NewReader(r)
+			for {
+				line, err := br.ReadString('\n')
+				if line != "" && !yield(line) {
+					return
+				}
+				if err != nil {
+					if err != io.EOF {
+						readErr = err
+					}
+					return
+				}
+			}
+		}
+		p, stop := newLinesParser(lines, opts)
+		defer stop()
+		done := false
+		p.parseDiffs(func(diff Diff, err error) bool {
+			if readErr != nil {
+				diff, err = Diff{}, readErr
+			}
+			done = !yield(diff, err) || err != nil
+			return !done
+		})
+		if !done && readErr != nil {
+			yield(Diff{}, readErr)
+		}
+	}
+}
+
+// ParsePatch parses a multi-file patch. Each group of blocks is preceded by
+// a "FILE: path" header naming the file it targets:
+//
+//	FILE: path/to/file
+//	<<<<<<< SEARCH line:n
+//	...
+//	>>>>>>> REPLACE
+//
+// Blocks for the same path are grouped together, and blocks that appear
+// before the first header are grouped under the empty path.
+//
+// Files can also be created, deleted, or renamed with the "NEW FILE: path",
+// "DELETE FILE: path", and "RENAME old -> new" headers. The blocks following
+// NEW FILE and RENAME headers are applied to the new file, and DELETE FILE
+// headers can't have any blocks.
+func ParsePatch(input string, opts ...ParseOption) (Patch, error) {
+	patch, errs := parsePatch(input, opts)
+	var err error
+	if len(errs) > 0 && !newParseConfig(opts).allErrors {
+		err = errs[0]
+	} else {
+		err = errors.Join(errs...)
+	}
+	if errors.Is(err, ErrTruncated) {
+		return patch, err
+	}
+	if err != nil {
+		return Patch{}, err
+	}
+	return patch, nil
+}
+
+// parsePatch parses the files in input. Unless WithAllErrors is used, it
+// stops at the first error.
+func parsePatch(input string, opts []ParseOption) (Patch, []error) {
+	p, stop := newParser(input, opts)
+	defer stop()
+	var patch Patch
+	var errs []error
+	if err := p.parseVersion(); err != nil {
+		return Patch{}, []error{err}
+	}
+	file := -1
+	for p.skipBlank(); p.peek().Type != EOF; p.skipBlank() {
+		if len(errs) > 0 && !p.cfg.allErrors {
+			break
+		}
+		header, ok, err := p.parseFileHeader()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			file = patch.add(header)
+			continue
+		}
+		if file >= 0 && patch.Files[file].Op == FileDelete {
+			errs = append(errs, p.peek().errorf("unexpected block for deleted file %q", patch.Files[file].OldPath))
+			p.read()
+			p.resync()
+			continue
+		}
+		diff, err := p.parseDiff()
+		if err != nil {
+			errs = append(errs, err)
+			p.resync()
+			continue
+		}
+		if file < 0 {
+			file = patch.file("")
+		}
+		patch.Files[file].Diffs = append(patch.Files[file].Diffs, diff)
+	}
+	return patch, errs
+}
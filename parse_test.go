@@ -1,12 +1,21 @@
 package fuzzypatch
 
 import (
+	"errors"
+	"io"
+	"iter"
 	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"gotest.tools/v3/assert"
 )
 
+// ignoreSpans ignores patch locations when comparing parsed diffs.
+var ignoreSpans = cmpopts.IgnoreFields(Diff{}, "Span")
+
 func TestTokenize(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -22,71 +31,79 @@ func TestTokenize(t *testing.T) {
 			name:  "single text line",
 			input: "hello world",
 			tokens: []token{
-				{Type: textType, Line: 0, Text: "hello world"},
-				{Type: EOF},
+				{Type: TextType, Line: 0, Text: "hello world"},
+				{Type: EOF, Line: 1},
 			},
 		},
 		{
 			name:  "single text line with newline",
 			input: "hello world\n",
 			tokens: []token{
-				{Type: textType, Line: 0, Text: "hello world\n"},
-				{Type: EOF},
+				{Type: TextType, Line: 0, Text: "hello world\n"},
+				{Type: EOF, Line: 1},
 			},
 		},
 		{
 			name:  "start search",
 			input: "<<<<<<< SEARCH line:1\n",
 			tokens: []token{
-				{Type: startSearchType, Line: 0, Text: "<<<<<<< SEARCH line:1\n"},
-				{Type: EOF},
+				{Type: StartSearchType, Line: 0, Text: "<<<<<<< SEARCH line:1\n"},
+				{Type: EOF, Line: 1},
 			},
 		},
 		{
 			name:  "separator",
 			input: "=======\n",
 			tokens: []token{
-				{Type: textSeparatorType, Line: 0, Text: "=======\n"},
-				{Type: EOF},
+				{Type: TextSeparatorType, Line: 0, Text: "=======\n"},
+				{Type: EOF, Line: 1},
 			},
 		},
 		{
 			name:  "end replace",
 			input: ">>>>>>> REPLACE\n",
 			tokens: []token{
-				{Type: endReplaceType, Line: 0, Text: ">>>>>>> REPLACE\n"},
-				{Type: EOF},
+				{Type: EndReplaceType, Line: 0, Text: ">>>>>>> REPLACE\n"},
+				{Type: EOF, Line: 1},
+			},
+		},
+		{
+			name:  "end delete",
+			input: ">>>>>>> DELETE\n",
+			tokens: []token{
+				{Type: EndDeleteType, Line: 0, Text: ">>>>>>> DELETE\n"},
+				{Type: EOF, Line: 1},
 			},
 		},
 		{
 			name:  "mixed lines with newlines",
 			input: "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
 			tokens: []token{
-				{Type: startSearchType, Line: 0, Text: "<<<<<<< SEARCH line:1\n"},
-				{Type: textType, Line: 1, Text: "foo\n"},
-				{Type: textSeparatorType, Line: 2, Text: "=======\n"},
-				{Type: textType, Line: 3, Text: "bar\n"},
-				{Type: endReplaceType, Line: 4, Text: ">>>>>>> REPLACE\n"},
-				{Type: EOF},
+				{Type: StartSearchType, Line: 0, Text: "<<<<<<< SEARCH line:1\n"},
+				{Type: TextType, Line: 1, Text: "foo\n"},
+				{Type: TextSeparatorType, Line: 2, Text: "=======\n"},
+				{Type: TextType, Line: 3, Text: "bar\n"},
+				{Type: EndReplaceType, Line: 4, Text: ">>>>>>> REPLACE\n"},
+				{Type: EOF, Line: 5},
 			},
 		},
 		{
 			name:  "mixed lines without trailing newline",
 			input: "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE",
 			tokens: []token{
-				{Type: startSearchType, Line: 0, Text: "<<<<<<< SEARCH line:1\n"},
-				{Type: textType, Line: 1, Text: "foo\n"},
-				{Type: textSeparatorType, Line: 2, Text: "=======\n"},
-				{Type: textType, Line: 3, Text: "bar\n"},
-				{Type: endReplaceType, Line: 4, Text: ">>>>>>> REPLACE"},
-				{Type: EOF},
+				{Type: StartSearchType, Line: 0, Text: "<<<<<<< SEARCH line:1\n"},
+				{Type: TextType, Line: 1, Text: "foo\n"},
+				{Type: TextSeparatorType, Line: 2, Text: "=======\n"},
+				{Type: TextType, Line: 3, Text: "bar\n"},
+				{Type: EndReplaceType, Line: 4, Text: ">>>>>>> REPLACE"},
+				{Type: EOF, Line: 5},
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tokens := slices.Collect(tokenize(tt.input))
+			tokens := slices.Collect(tokenize(strings.Lines(tt.input), newParseConfig(nil)))
 			assert.DeepEqual(t, tokens, tt.tokens)
 		})
 	}
@@ -145,6 +162,175 @@ func TestParse(t *testing.T) {
 			diffs: nil,
 			err:   true,
 		},
+		{
+			name:  "comments between blocks",
+			input: "# rename foo\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n\n# trailing comment\n",
+			diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}},
+		},
+		{
+			name:  "deletion",
+			input: "<<<<<<< SEARCH line:2\nfoo\nbar\n>>>>>>> DELETE\n",
+			diffs: []Diff{{Line: 2, Search: "foo\nbar\n"}},
+		},
+		{
+			name:  "deletion with empty replace",
+			input: "<<<<<<< SEARCH line:2\nfoo\n=======\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Line: 2, Search: "foo\n"}},
+		},
+		{
+			name:  "delete marker after separator",
+			input: "<<<<<<< SEARCH line:2\nfoo\n=======\nbar\n>>>>>>> DELETE\n",
+			err:   true,
+		},
+		{
+			name:  "missing line hint",
+			input: "<<<<<<< SEARCH\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Search: "foo\n", Replace: "bar\n"}},
+		},
+		{
+			name:  "invalid line hint",
+			input: "<<<<<<< SEARCH line:abc\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			err:   true,
+		},
+		{
+			name:  "append",
+			input: "<<<<<<< SEARCH line:$\n=======\nbar\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Line: EndOfFile, Replace: "bar\n"}},
+		},
+		{
+			name:  "indented block",
+			input: "  <<<<<<< SEARCH line:1\n  foo\n    bar\n\n  =======\n  baz\n  >>>>>>> REPLACE\n",
+			diffs: []Diff{{Line: 1, Search: "foo\n  bar\n\n", Replace: "baz\n"}},
+		},
+		{
+			name:  "indented markers inside a block are text",
+			input: "<<<<<<< SEARCH line:1\n  =======\n=======\n  >>>>>>> REPLACE\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Line: 1, Search: "  =======\n", Replace: "  >>>>>>> REPLACE\n"}},
+		},
+		{
+			name: "blocks with different indentation",
+			input: "\t<<<<<<< SEARCH line:1\n\tfoo\n\t=======\n\tbar\n\t>>>>>>> REPLACE\n" +
+				"<<<<<<< SEARCH line:2\n\tbaz\n=======\n\tqux\n>>>>>>> REPLACE\n",
+			diffs: []Diff{
+				{Line: 1, Search: "foo\n", Replace: "bar\n"},
+				{Line: 2, Search: "\tbaz\n", Replace: "\tqux\n"},
+			},
+		},
+		{
+			name:  "byte order mark",
+			input: "\uFEFF<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}},
+		},
+		{
+			name:  "prepend",
+			input: "<<<<<<< SEARCH line:0\n=======\nbar\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Line: 0, Replace: "bar\n"}},
+		},
+		{
+			name:  "negative line hint",
+			input: "<<<<<<< SEARCH line:-1\n=======\nbar\n>>>>>>> REPLACE\n",
+			err:   true,
+		},
+		{
+			name:  "line range",
+			input: "<<<<<<< SEARCH line:10-40\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Line: 10, LineEnd: 40, Search: "foo\n", Replace: "bar\n"}},
+		},
+		{
+			name:  "backwards line range",
+			input: "<<<<<<< SEARCH line:40-10\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			err:   true,
+		},
+		{
+			name:  "occurrence",
+			input: "<<<<<<< SEARCH line:4 occurrence:2\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Line: 4, Occurrence: 2, Search: "foo\n", Replace: "bar\n"}},
+		},
+		{
+			name:  "invalid occurrence",
+			input: "<<<<<<< SEARCH line:4 occurrence:0\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			err:   true,
+		},
+		{
+			name:  "regex",
+			input: "<<<<<<< SEARCH regex:true\nv\\d+\n=======\nv2\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Regex: true, Search: "v\\d+\n", Replace: "v2\n"}},
+		},
+		{
+			name:  "column",
+			input: "<<<<<<< SEARCH line:3 column:7\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Line: 3, Column: 7, Search: "foo\n", Replace: "bar\n"}},
+		},
+		{
+			name:  "fingerprint",
+			input: "<<<<<<< SEARCH line:3 fingerprint:1a2b3c4d\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Line: 3, Fingerprint: "1a2b3c4d", Search: "foo\n", Replace: "bar\n"}},
+		},
+		{
+			name:  "context",
+			input: "<<<<<<< SEARCH context:1,0\nfunc f() {\n\treturn 1\n=======\n\treturn 2\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{ContextBefore: 1, Search: "func f() {\n\treturn 1\n", Replace: "\treturn 2\n"}},
+		},
+		{
+			name:  "context on both sides",
+			input: "<<<<<<< SEARCH context:1\na\nb\nc\n=======\nB\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{ContextBefore: 1, ContextAfter: 1, Search: "a\nb\nc\n", Replace: "B\n"}},
+		},
+		{
+			name:  "reject",
+			input: "<<<<<<< SEARCH reject:\"if err != nil\" reject:guard() reject_radius:2\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Reject: []string{"if err != nil", "guard()"}, RejectRadius: 2, Search: "foo\n", Replace: "bar\n"}},
+		},
+		{
+			name:  "unterminated reject text",
+			input: "<<<<<<< SEARCH reject:\"if err\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			err:   true,
+		},
+		{
+			name:  "invalid reject radius",
+			input: "<<<<<<< SEARCH reject_radius:-1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			err:   true,
+		},
+		{
+			name:  "invalid context",
+			input: "<<<<<<< SEARCH context:1,x\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			err:   true,
+		},
+		{
+			name:  "invalid column",
+			input: "<<<<<<< SEARCH column:0\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			err:   true,
+		},
+		{
+			name:  "invalid regex flag",
+			input: "<<<<<<< SEARCH regex:maybe\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			err:   true,
+		},
+		{
+			name:  "threshold",
+			input: "<<<<<<< SEARCH threshold:0.95 line:3\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Line: 3, Threshold: 0.95, Search: "foo\n", Replace: "bar\n"}},
+		},
+		{
+			name:  "threshold out of range",
+			input: "<<<<<<< SEARCH line:3 threshold:1.5\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			err:   true,
+		},
+		{
+			name: "escaped markers",
+			input: "<<<<<<< SEARCH line:1\n\\=======\n\\>>>>>>> REPLACE\n\\\\=======\n\\n\n=======\n" +
+				"\\<<<<<<< SEARCH line:2\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{
+				Line:    1,
+				Search:  "=======\n>>>>>>> REPLACE\n\\=======\n\\n\n",
+				Replace: "<<<<<<< SEARCH line:2\n",
+			}},
+		},
+		{
+			name:  "unknown attribute",
+			input: "<<<<<<< SEARCH line:1 color:red\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			err:   true,
+		},
 		{
 			name:  "multi-line search and replace",
 			input: "<<<<<<< SEARCH line:34\nfoo\nbar\n=======\nbaz\nqux\n>>>>>>> REPLACE\n",
@@ -167,7 +353,415 @@ func TestParse(t *testing.T) {
 				return
 			}
 			assert.NilError(t, err)
-			assert.DeepEqual(t, diffs, tt.diffs)
+			assert.DeepEqual(t, diffs, tt.diffs, ignoreSpans)
+		})
+	}
+}
+
+func TestParsePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		patch Patch
+		err   bool
+	}{
+		{
+			name:  "empty input",
+			input: "",
+			patch: Patch{},
+		},
+		{
+			name: "multiple files",
+			input: "FILE: a.txt\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n\n" +
+				"FILE: b.txt\n<<<<<<< SEARCH line:2\nbaz\n=======\nqux\n>>>>>>> REPLACE\n",
+			patch: Patch{Files: []FileDiff{
+				{Path: "a.txt", OldPath: "a.txt", Diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}}},
+				{Path: "b.txt", OldPath: "b.txt", Diffs: []Diff{{Line: 2, Search: "baz\n", Replace: "qux\n"}}},
+			}},
+		},
+		{
+			name: "repeated file is grouped",
+			input: "FILE: a.txt\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+				"FILE: b.txt\n" +
+				"FILE: a.txt\n<<<<<<< SEARCH line:5\nbaz\n=======\nqux\n>>>>>>> REPLACE\n",
+			patch: Patch{Files: []FileDiff{
+				{Path: "a.txt", OldPath: "a.txt", Diffs: []Diff{
+					{Line: 1, Search: "foo\n", Replace: "bar\n"},
+					{Line: 5, Search: "baz\n", Replace: "qux\n"},
+				}},
+				{Path: "b.txt", OldPath: "b.txt"},
+			}},
+		},
+		{
+			name: "file operations",
+			input: "NEW FILE: new.txt\n<<<<<<< SEARCH\n=======\nhello\n>>>>>>> REPLACE\n" +
+				"DELETE FILE: old.txt\n" +
+				"RENAME a.txt -> b.txt\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+				"FILE: b.txt\n<<<<<<< SEARCH line:2\nbaz\n=======\nqux\n>>>>>>> REPLACE\n",
+			patch: Patch{Files: []FileDiff{
+				{Path: "new.txt", Op: FileCreate, Diffs: []Diff{{Replace: "hello\n"}}},
+				{OldPath: "old.txt", Op: FileDelete},
+				{Path: "b.txt", OldPath: "a.txt", Op: FileRename, Diffs: []Diff{
+					{Line: 1, Search: "foo\n", Replace: "bar\n"},
+					{Line: 2, Search: "baz\n", Replace: "qux\n"},
+				}},
+			}},
+		},
+		{
+			name:  "block after delete",
+			input: "DELETE FILE: old.txt\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			err:   true,
+		},
+		{
+			name:  "invalid rename",
+			input: "RENAME a.txt b.txt\n",
+			err:   true,
+		},
+		{
+			name:  "blocks without a header",
+			input: "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			patch: Patch{Files: []FileDiff{
+				{Diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}}},
+			}},
+		},
+		{
+			name:  "invalid block",
+			input: "FILE: a.txt\n<<<<<<< SEARCH line:1\nfoo\n",
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := ParsePatch(tt.input)
+			if tt.err {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, patch, tt.patch, ignoreSpans)
+			for _, f := range tt.patch.Files {
+				assert.DeepEqual(t, patch.Diffs(f.Path), f.Diffs, ignoreSpans)
+			}
+		})
+	}
+}
+
+func TestParseWithMarkers(t *testing.T) {
+	input := "<<< FIND line:3\nfoo\n---\nbar\n>>> END\n"
+	diffs, err := Parse(input, WithMarkers("<<< FIND", "---", ">>> END"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, diffs, []Diff{{Line: 3, Search: "foo\n", Replace: "bar\n"}}, ignoreSpans)
+
+	// the default markers are just text with custom markers
+	_, err = Parse("<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n", WithMarkers("<<< FIND", "", ""))
+	assert.Assert(t, err != nil)
+
+	// empty markers keep the defaults
+	diffs, err = Parse("<<< FIND line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n", WithMarkers("<<< FIND", "", ""))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, diffs, []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}}, ignoreSpans)
+}
+
+func TestParseLenient(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		diffs  []Diff
+		errors int
+	}{
+		{
+			name:  "valid input",
+			input: "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}},
+		},
+		{
+			name: "missing end marker",
+			input: "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n" +
+				"<<<<<<< SEARCH line:3\nbaz\n=======\nqux\n>>>>>>> REPLACE\n",
+			diffs:  []Diff{{Line: 3, Search: "baz\n", Replace: "qux\n"}},
+			errors: 1,
+		},
+		{
+			name: "garbage between blocks",
+			input: "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+				"some garbage\n=======\n" +
+				"<<<<<<< SEARCH line:3\nbaz\n=======\nqux\n>>>>>>> REPLACE\n",
+			diffs: []Diff{
+				{Line: 1, Search: "foo\n", Replace: "bar\n"},
+				{Line: 3, Search: "baz\n", Replace: "qux\n"},
+			},
+			errors: 1,
+		},
+		{
+			name: "bad line hint",
+			input: "<<<<<<< SEARCH line:x\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+				"<<<<<<< SEARCH line:3\nbaz\n=======\nqux\n>>>>>>> REPLACE\n",
+			diffs:  []Diff{{Line: 3, Search: "baz\n", Replace: "qux\n"}},
+			errors: 1,
+		},
+		{
+			name:   "truncated",
+			input:  "<<<<<<< SEARCH line:1\nfoo\n",
+			errors: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, errs := ParseLenient(tt.input)
+			assert.DeepEqual(t, diffs, tt.diffs, ignoreSpans)
+			assert.Equal(t, len(errs), tt.errors)
+		})
+	}
+}
+
+func TestParseReader(t *testing.T) {
+	r, w := io.Pipe()
+	next, stop := iter.Pull2(ParseReader(r))
+	defer stop()
+
+	go io.WriteString(w, "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n")
+	diff, err, ok := next()
+	assert.Assert(t, ok)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, diff, Diff{Line: 1, Search: "foo\n", Replace: "bar\n"}, ignoreSpans)
+
+	go func() {
+		io.WriteString(w, "\n<<<<<<< SEARCH line:2\nbaz\n=======\n")
+		w.CloseWithError(errors.New("connection reset"))
+	}()
+	_, err, ok = next()
+	assert.Assert(t, ok)
+	assert.ErrorContains(t, err, "connection reset")
+
+	_, _, ok = next()
+	assert.Assert(t, !ok)
+}
+
+func TestParseSeq(t *testing.T) {
+	input := "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+		"<<<<<<< SEARCH line:2\nbaz\n=======\nqux\n>>>>>>> REPLACE\n" +
+		"this is not a block\n"
+
+	// stopping early never reaches the invalid trailer
+	var diffs []Diff
+	for diff, err := range ParseSeq(input) {
+		assert.NilError(t, err)
+		diffs = append(diffs, diff)
+		if len(diffs) == 2 {
+			break
+		}
+	}
+	assert.DeepEqual(t, diffs, []Diff{
+		{Line: 1, Search: "foo\n", Replace: "bar\n"},
+		{Line: 2, Search: "baz\n", Replace: "qux\n"},
+	}, ignoreSpans)
+
+	// iterating to the end yields the error
+	var errs []error
+	for _, err := range ParseSeq(input) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	assert.Equal(t, len(errs), 1)
+}
+
+func TestParsePatchAider(t *testing.T) {
+	input := "main.go\n<<<<<<< SEARCH\nfoo\n=======\nbar\n>>>>>>> REPLACE\n\n" +
+		"lib/util.go\n<<<<<<< SEARCH\nbaz\n=======\nqux\n>>>>>>> REPLACE\n"
+	patch, err := ParsePatch(input, WithAider())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, patch, Patch{Files: []FileDiff{
+		{Path: "main.go", OldPath: "main.go", Diffs: []Diff{{Search: "foo\n", Replace: "bar\n"}}},
+		{Path: "lib/util.go", OldPath: "lib/util.go", Diffs: []Diff{{Search: "baz\n", Replace: "qux\n"}}},
+	}}, ignoreSpans)
+
+	// without the option the path is not part of the format
+	_, err = ParsePatch(input)
+	assert.Assert(t, err != nil)
+
+	// a path that isn't followed by a block is still an error
+	_, err = ParsePatch("main.go\nfoo\n", WithAider())
+	assert.Assert(t, err != nil)
+}
+
+func TestParseWithComments(t *testing.T) {
+	input := "# first\n<<<<<<< SEARCH line:1\n# not a comment\n=======\nbar\n>>>>>>> REPLACE\n" +
+		"\n#second\n\n# spans lines\n<<<<<<< SEARCH line:2\nbaz\n=======\nqux\n>>>>>>> REPLACE\n" +
+		"<<<<<<< SEARCH line:3\na\n=======\nb\n>>>>>>> REPLACE\n"
+	diffs, err := Parse(input, WithComments())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, diffs, []Diff{
+		{Line: 1, Search: "# not a comment\n", Replace: "bar\n", Comment: "first"},
+		{Line: 2, Search: "baz\n", Replace: "qux\n", Comment: "second\nspans lines"},
+		{Line: 3, Search: "a\n", Replace: "b\n"},
+	}, ignoreSpans)
+}
+
+func TestParseSpans(t *testing.T) {
+	input := "# comment\n" +
+		"<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+		"\n" +
+		"<<<<<<< SEARCH line:3\nbaz\nqux\n>>>>>>> DELETE\n"
+	diffs, err := Parse(input)
+	assert.NilError(t, err)
+	assert.Equal(t, len(diffs), 2)
+	assert.DeepEqual(t, diffs[0].Span, Span{Start: 2, End: 6})
+	assert.DeepEqual(t, diffs[1].Span, Span{Start: 8, End: 11})
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   *ParseError
+	}{
+		{
+			name:  "missing separator",
+			input: "<<<<<<< SEARCH line:1\nfoo\n>>>>>>> REPLACE\n",
+			err: &ParseError{
+				Line:     3,
+				Expected: TextSeparatorType,
+				Got:      EndReplaceType,
+				Text:     ">>>>>>> REPLACE\n",
+				Block:    1,
+			},
+		},
+		{
+			name:  "missing end",
+			input: "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n",
+			err: &ParseError{
+				Line:     5,
+				Expected: EndReplaceType,
+				Got:      EOF,
+				Block:    1,
+				Err:      ErrTruncated,
+			},
+		},
+		{
+			name:  "unknown attribute",
+			input: "\n<<<<<<< SEARCH line:1 color:red\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			err: &ParseError{
+				Line:  2,
+				Got:   StartSearchType,
+				Text:  "<<<<<<< SEARCH line:1 color:red\n",
+				Msg:   `unknown attribute "color"`,
+				Block: 1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var perr *ParseError
+			assert.Assert(t, errors.As(err, &perr))
+			assert.DeepEqual(t, *perr, *tt.err, cmpopts.EquateErrors())
+		})
+	}
+}
+
+func TestParseErrorWrapped(t *testing.T) {
+	_, err := Parse("<<<<<<< SEARCH line:x\nfoo\n=======\nbar\n>>>>>>> REPLACE\n")
+	assert.ErrorContains(t, err, `invalid line hint "x"`)
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+}
+
+func TestParseTruncated(t *testing.T) {
+	input := "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+		"<<<<<<< SEARCH line:5\nbaz\n=======\n"
+	diffs, err := Parse(input)
+	assert.ErrorIs(t, err, ErrTruncated)
+	assert.DeepEqual(t, diffs, []Diff{{Line: 1, Search: "foo\n", Replace: "bar\n"}}, ignoreSpans)
+	var perr *ParseError
+	assert.Assert(t, errors.As(err, &perr))
+	assert.Equal(t, perr.Block, 2)
+
+	patch, err := ParsePatch("FILE: a.txt\n" + input)
+	assert.ErrorIs(t, err, ErrTruncated)
+	assert.DeepEqual(t, patch.Diffs("a.txt"), diffs, ignoreSpans)
+}
+
+func TestParseWithAllErrors(t *testing.T) {
+	input := "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+		"<<<<<<< SEARCH line:x\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+		"<<<<<<< SEARCH line:3\nfoo\n>>>>>>> REPLACE\n"
+
+	// only the first error is reported by default
+	_, err := Parse(input)
+	assert.ErrorContains(t, err, "block 2, line 6")
+	assert.Assert(t, !strings.Contains(err.Error(), "block 3"))
+
+	_, err = Parse(input, WithAllErrors())
+	assert.ErrorContains(t, err, "block 2, line 6")
+	assert.ErrorContains(t, err, "block 3, line 13")
+	var blocks []int
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var perr *ParseError
+		assert.Assert(t, errors.As(err, &perr))
+		blocks = append(blocks, perr.Block)
+	}
+	assert.DeepEqual(t, blocks, []int{2, 3})
+
+	_, err = ParsePatch("FILE: a.txt\n"+input, WithAllErrors())
+	assert.ErrorContains(t, err, "block 2, line 7")
+	assert.ErrorContains(t, err, "block 3, line 14")
+
+	diffs, err := Parse(input[:strings.Index(input, "<<<<<<< SEARCH line:x")], WithAllErrors())
+	assert.NilError(t, err)
+	assert.Equal(t, len(diffs), 1)
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		diffs []Diff
+		err   string
+	}{
+		{
+			name:  "current version",
+			input: "FUZZYPATCH v2\n\n<<<<<<< SEARCH line:$\nfoo\n>>>>>>> DELETE\n",
+			diffs: []Diff{{Line: EndOfFile, Search: "foo\n"}},
+		},
+		{
+			name:  "version 1 has no delete blocks",
+			input: "FUZZYPATCH v1\n<<<<<<< SEARCH line:1\nfoo\n>>>>>>> DELETE\n=======\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Line: 1, Search: "foo\n>>>>>>> DELETE\n"}},
+		},
+		{
+			name:  "version 1 has no escapes",
+			input: "FUZZYPATCH v1\n<<<<<<< SEARCH line:1\n\\=======\n=======\n>>>>>>> REPLACE\n",
+			diffs: []Diff{{Line: 1, Search: "\\=======\n"}},
+		},
+		{
+			name:  "version 1 has no attributes",
+			input: "FUZZYPATCH v1\n<<<<<<< SEARCH line:1 occurrence:2\nfoo\n=======\n>>>>>>> REPLACE\n",
+			err:   `unknown attribute "occurrence"`,
+		},
+		{
+			name:  "version 1 has no end of file hint",
+			input: "FUZZYPATCH v1\n<<<<<<< SEARCH line:$\nfoo\n=======\n>>>>>>> REPLACE\n",
+			err:   `invalid line hint "$"`,
+		},
+		{
+			name:  "unknown version",
+			input: "# generated\nFUZZYPATCH v9\n<<<<<<< SEARCH line:1\nfoo\n=======\n>>>>>>> REPLACE\n",
+			err:   `unsupported patch version "v9": "FUZZYPATCH v9\n" (line 2)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := Parse(tt.input)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, diffs, tt.diffs, ignoreSpans)
 		})
 	}
 }
@@ -0,0 +1,146 @@
+package fuzzypatch
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrAlreadyApplied is the error of a FailedDiff whose replace text is
+// already in the source, such as when a patch is applied a second time.
+var ErrAlreadyApplied = errors.New("already applied")
+
+// FailedDiff is a diff that ApplyDiffs couldn't apply.
+type FailedDiff struct {
+	Diff  Diff
+	Index int   // Index of the diff in the list given to ApplyDiffs
+	Err   error // Why it failed, such as ErrNoMatch, ErrAlreadyApplied, or ErrOverlap
+}
+
+// ApplyDiffs searches for each of the diffs in source, and applies the ones
+// that matched. Unlike searching for all of them and calling Apply, a diff
+// that fails doesn't stop the others from being applied, and the failures
+// are returned along with the patched text. Each diff is kept from matching
+// text that an earlier diff already changed, as with WithExclude, and diffs
+// that don't match but are already applied fail with ErrAlreadyApplied. The
+// check only runs when a diff doesn't match, so a diff whose search text is
+// still there after it's applied, such as one that appends lines after it,
+// is applied again.
+func ApplyDiffs(source string, diffs []Diff, opts ...SearchOption) (string, []FailedDiff) {
+	var failed []FailedDiff
+	var edits []Edit
+	for i, r := range searchDiffs(source, diffs, opts) {
+		if r.Err != nil {
+			failed = append(failed, FailedDiff{Diff: diffs[i], Index: i, Err: r.Err})
+			continue
+		}
+		edits = append(edits, r.Match.Edit)
+	}
+	out, _ := Apply(source, edits)
+	return out, failed
+}
+
+// searchDiffs searches for each of the diffs in order, keeping them from
+// matching text that an earlier diff changed, and detecting the diffs that
+// were already applied. The exclusions should keep the matches apart, but
+// the matches that would still overlap fail with ErrOverlap, so the edits
+// of the rest can always be applied.
+func searchDiffs(source string, diffs []Diff, opts []SearchOption) []DiffResult {
+	results := make([]DiffResult, len(diffs))
+	var edits []Edit
+	var indexes []int // the index of the diff each edit is for
+	for i, d := range diffs {
+		opts := append(slices.Clip(opts), WithExclude(edits...))
+		m, err := SearchMatch(source, d, opts...)
+		if errors.Is(err, ErrNoMatch) && IsApplied(source, d, opts...) {
+			err = ErrAlreadyApplied
+		}
+		results[i] = DiffResult{Match: m, Err: err}
+		if err == nil {
+			edits = append(edits, m.Edit)
+			indexes = append(indexes, i)
+		}
+	}
+	plan, _ := planEdits(source, edits, newApplyConfig([]ApplyOption{WithOverlap(OverlapSkip)}))
+	for k, r := range plan.results {
+		if r.Overlapped {
+			results[indexes[k]] = DiffResult{Err: ErrOverlap}
+		}
+	}
+	return results
+}
+
+// Report describes whether each of the diffs given to Check would apply.
+type Report struct {
+	Results []DiffResult // One for each diff, in the same order
+}
+
+func (r Report) String() string {
+	n := 0
+	for _, res := range r.Results {
+		if res.Err == nil {
+			n++
+		}
+	}
+	return fmt.Sprintf("%d of %d diffs apply", n, len(r.Results))
+}
+
+// Check is like ApplyDiffs, but it only reports which of the diffs would
+// apply, with their matches, without producing the patched text. The error
+// joins the errors of the diffs that wouldn't apply, each prefixed by the
+// diff's 1-based index, and it's nil when they all would.
+func Check(source string, diffs []Diff, opts ...SearchOption) (Report, error) {
+	report := Report{Results: searchDiffs(source, diffs, opts)}
+	var errs []error
+	for i, r := range report.Results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("diff %d: %w", i+1, r.Err))
+		}
+	}
+	return report, errors.Join(errs...)
+}
+
+// appliedRadius is how many lines from a diff's line hint IsApplied looks
+// for its replace text.
+const appliedRadius = 3
+
+// IsApplied reports whether the text that would be left by applying diff is
+// found in source, exactly and within a few lines of its line hint. Diffs
+// without a line hint are looked for in the whole source. Only the range and
+// exclude options are used, the rest are ignored so that text which differs
+// in case or whitespace doesn't count. Diffs that only delete text can't be
+// detected, and neither can Regex diffs.
+func IsApplied(source string, diff Diff, opts ...SearchOption) bool {
+	reversed, err := Reverse([]Diff{diff})
+	if err != nil || reversed[0].Search == "" {
+		return false
+	}
+	d := reversed[0]
+	d.Threshold = 1
+	cfg := newSearchConfig(opts)
+	_, err = SearchMatch(source, d, WithMaxRadius(appliedRadius), func(c *searchConfig) {
+		c.lineRange, c.byteRange, c.exclude = cfg.lineRange, cfg.byteRange, cfg.exclude
+	})
+	return err == nil
+}
+
+// FormatRejects formats the failed diffs like a .rej file, as SEARCH/REPLACE
+// blocks which can be fixed and retried with Parse. Each block starts with a
+// comment saying why it failed, followed by the diff's own comment. Diffs
+// that were already applied are left out.
+func FormatRejects(failed []FailedDiff) string {
+	var diffs []Diff
+	for _, f := range failed {
+		if errors.Is(f.Err, ErrAlreadyApplied) {
+			continue
+		}
+		d := f.Diff
+		comment := "rejected: " + f.Err.Error()
+		if d.Comment != "" {
+			comment += "\n" + d.Comment
+		}
+		d.Comment = comment
+		diffs = append(diffs, d)
+	}
+	return Format(diffs)
+}
@@ -0,0 +1,144 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestApplyDiffs(t *testing.T) {
+	source := "a\nb\nc\nd\n"
+	diffs := []Diff{
+		{Line: 1, Search: "a\n", Replace: "A\n"},
+		{Line: 2, Search: "missing\n", Replace: "x\n"},
+		{Line: 3, Search: "c\n", Replace: "C\n"},
+		{Line: 1, Search: "a\nb\n", Replace: "x\n"},
+		{Regex: true, Search: "(", Replace: "x\n"},
+	}
+	got, failed := ApplyDiffs(source, diffs)
+	assert.Equal(t, got, "A\nb\nC\nd\n")
+	assert.Equal(t, len(failed), 3)
+	assert.Equal(t, failed[0].Index, 1)
+	assert.DeepEqual(t, failed[0].Diff, diffs[1])
+	assert.ErrorIs(t, failed[0].Err, ErrNoMatch)
+	assert.Equal(t, failed[1].Index, 3)
+	assert.ErrorIs(t, failed[1].Err, ErrNoMatch)
+	assert.Equal(t, failed[2].Index, 4)
+	assert.ErrorContains(t, failed[2].Err, "missing closing )")
+
+	got, failed = ApplyDiffs(source, nil)
+	assert.Equal(t, got, source)
+	assert.Equal(t, len(failed), 0)
+}
+
+func TestApplyDiffsExclude(t *testing.T) {
+	// the second diff can't replace the text around the insertion
+	source := "a\nb\n"
+	diffs := []Diff{
+		{Line: 2, Replace: "new\n"},
+		{Line: 1, Search: "a\nb\n", Replace: "x\n"},
+	}
+	got, failed := ApplyDiffs(source, diffs)
+	assert.Equal(t, got, "a\nnew\nb\n")
+	assert.Equal(t, len(failed), 1)
+	assert.Equal(t, failed[0].Index, 1)
+	assert.ErrorIs(t, failed[0].Err, ErrNoMatch)
+}
+
+func TestFormatRejects(t *testing.T) {
+	failed := []FailedDiff{
+		{Diff: Diff{Line: 2, Search: "missing\n", Replace: "x\n"}, Index: 1, Err: ErrNoMatch},
+		{Diff: Diff{Search: "a\n", Replace: "b\n", Comment: "rename a"}, Index: 4, Err: ErrOverlap},
+	}
+	rej := FormatRejects(failed)
+	assert.Equal(t, rej, "# rejected: no match\n<<<<<<< SEARCH line:2\nmissing\n=======\nx\n>>>>>>> REPLACE\n\n"+
+		"# rejected: overlapping edits\n# rename a\n<<<<<<< SEARCH\na\n=======\nb\n>>>>>>> REPLACE\n")
+
+	diffs, err := Parse(rej)
+	assert.NilError(t, err)
+	assert.Equal(t, len(diffs), 2)
+	assert.Equal(t, diffs[1].Search, "a\n")
+
+	assert.Equal(t, FormatRejects(nil), "")
+}
+
+func TestApplyDiffsAlreadyApplied(t *testing.T) {
+	source := "func f() {\n\treturn 1\n}\n\nfunc g() {}\n"
+	diffs := []Diff{
+		{Line: 2, Search: "\treturn 1\n", Replace: "\treturn x + y\n"},
+		{Line: 5, Replace: "// g does nothing\n"},
+		{Line: 1, Search: "func f() {\n\treturn 1\n", Replace: "\treturn x + y\n", ContextBefore: 1},
+	}
+	patched, failed := ApplyDiffs(source, diffs[:2])
+	assert.Equal(t, len(failed), 0)
+	assert.Equal(t, patched, "func f() {\n\treturn x + y\n}\n\n// g does nothing\nfunc g() {}\n")
+
+	// applying the patch again changes nothing
+	got, failed := ApplyDiffs(patched, diffs[:1])
+	assert.Equal(t, got, patched)
+	assert.Equal(t, len(failed), 1)
+	assert.ErrorIs(t, failed[0].Err, ErrAlreadyApplied)
+	assert.Equal(t, FormatRejects(failed), "")
+
+	assert.Assert(t, IsApplied(patched, diffs[1]))
+	assert.Assert(t, IsApplied(patched, diffs[2]))
+	assert.Assert(t, !IsApplied(source, diffs[0]))
+	assert.Assert(t, !IsApplied(patched, Diff{Search: "x\n"}))
+
+	// the replace text is only looked for near the hint
+	source = "func f() error {\n\tif err := g(); err != nil {\n\t\treturn fmt.Errorf(\"g: %w\", err)\n\t}\n\treturn nil\n}\n"
+	diff := Diff{Line: 2, Search: "\tif err != nil {\n\t\treturn err\n", Replace: "}\n"}
+	assert.Assert(t, !IsApplied(source, diff))
+	_, failed = ApplyDiffs(source, []Diff{diff})
+	assert.Equal(t, len(failed), 1)
+	assert.ErrorIs(t, failed[0].Err, ErrNoMatch)
+
+	// and it has to be exact, whatever the threshold
+	assert.Assert(t, !IsApplied("func f() {\n\treturn 2\n}\n", diffs[0], WithThresholdPolicy(LengthAdaptive(0.5, 40))))
+	rename := Diff{Line: 1, Search: "func bar() {\n", Replace: "func foo() {\n"}
+	assert.Assert(t, !IsApplied("func Foo() {\n}\n", rename, WithCaseFolding()))
+	assert.Assert(t, !IsApplied("func   foo() {\n}\n", rename, WithWhitespace(WhitespaceCollapse)))
+	_, failed = ApplyDiffs("func Foo() {\n}\n", []Diff{rename}, WithCaseFolding())
+	assert.Equal(t, len(failed), 1)
+	assert.ErrorIs(t, failed[0].Err, ErrNoMatch)
+
+	// appending lines leaves the search text in place, so it's applied again
+	appendDiff := Diff{Line: 1, Search: "a\n", Replace: "a\nb\n"}
+	got, failed = ApplyDiffs("a\nb\nc\n", []Diff{appendDiff})
+	assert.Equal(t, len(failed), 0)
+	assert.Equal(t, got, "a\nb\nb\nc\n")
+}
+
+func TestCheck(t *testing.T) {
+	source := "a\nb\nc\n"
+	diffs := []Diff{
+		{Line: 1, Search: "a\n", Replace: "A\n"},
+		{Line: 2, Search: "missing\n", Replace: "x\n"},
+		{Line: 3, Search: "c\n", Replace: "C\n"},
+	}
+	report, err := Check(source, diffs)
+	assert.Error(t, err, "diff 2: no match")
+	assert.ErrorIs(t, err, ErrNoMatch)
+	assert.Equal(t, report.String(), "2 of 3 diffs apply")
+	assert.Equal(t, len(report.Results), 3)
+	assert.DeepEqual(t, report.Results[0].Match, Match{Edit: Edit{Start: 0, End: 2, Text: "A\n"}, Score: 1, Line: 1, LineEnd: 1})
+	assert.Equal(t, report.Results[2].Match.Line, 3)
+
+	report, err = Check(source, []Diff{diffs[0], diffs[2]})
+	assert.NilError(t, err)
+	assert.Equal(t, report.String(), "2 of 2 diffs apply")
+
+	// an insertion inside of an earlier diff's match fails in both
+	diffs = []Diff{
+		{Line: 1, Search: "a\nb\n", Replace: "x\n"},
+		{Line: 2, Replace: "new\n"},
+	}
+	report, err = Check(source, diffs)
+	assert.ErrorIs(t, err, ErrNoMatch)
+	assert.Equal(t, report.String(), "1 of 2 diffs apply")
+	patched, failed := ApplyDiffs(source, diffs)
+	assert.Equal(t, patched, "x\nc\n")
+	assert.Equal(t, len(failed), 1)
+	assert.Equal(t, failed[0].Index, 1)
+	assert.ErrorIs(t, failed[0].Err, ErrNoMatch)
+}
@@ -0,0 +1,95 @@
+package fuzzypatch
+
+import (
+	"fmt"
+	"slices"
+)
+
+// FileOp is the operation a FileDiff performs on its file.
+type FileOp int
+
+const (
+	FileModify FileOp = iota // The Diffs are applied to an existing file
+	FileCreate               // The file is created by applying the Diffs to empty content
+	FileDelete               // The file is deleted
+	FileRename               // The file is moved from OldPath to Path and the Diffs are applied
+)
+
+var fileOpNames = [...]string{
+	FileModify: "modify",
+	FileCreate: "create",
+	FileDelete: "delete",
+	FileRename: "rename",
+}
+
+// String returns the name of the operation, such as "create".
+func (op FileOp) String() string {
+	if op < 0 || int(op) >= len(fileOpNames) {
+		return fmt.Sprintf("FileOp(%d)", int(op))
+	}
+	return fileOpNames[op]
+}
+
+// MarshalText encodes the operation as its name.
+func (op FileOp) MarshalText() ([]byte, error) {
+	if op < 0 || int(op) >= len(fileOpNames) {
+		return nil, fmt.Errorf("invalid file op %d", int(op))
+	}
+	return []byte(fileOpNames[op]), nil
+}
+
+// UnmarshalText decodes an operation name produced by MarshalText.
+func (op *FileOp) UnmarshalText(text []byte) error {
+	i := slices.Index(fileOpNames[:], string(text))
+	if i < 0 {
+		return fmt.Errorf("invalid file op %q", text)
+	}
+	*op = FileOp(i)
+	return nil
+}
+
+// FileDiff is the list of diffs that target a single file.
+type FileDiff struct {
+	Path    string // Path of the file after the change, empty if deleted
+	OldPath string // Path of the file before the change, empty if created
+	OldMode string // File mode before the change, if known
+	NewMode string // File mode after the change, if known
+	Op      FileOp // Operation performed on the file
+	Diffs   []Diff // Diffs to apply to the file
+}
+
+// Patch is a set of diffs grouped by the file they target.
+type Patch struct {
+	Files []FileDiff
+}
+
+// Diffs returns the diffs targeting path.
+func (p Patch) Diffs(path string) []Diff {
+	for _, f := range p.Files {
+		if f.Path == path && f.Op != FileDelete {
+			return f.Diffs
+		}
+	}
+	return nil
+}
+
+// add adds the file header to the patch and returns its index.
+// Modifications are merged with any existing entry for the same path.
+func (p *Patch) add(header FileDiff) int {
+	if header.Op == FileModify {
+		return p.file(header.Path)
+	}
+	p.Files = append(p.Files, header)
+	return len(p.Files) - 1
+}
+
+// file returns the index of the FileDiff for path, adding one if needed.
+func (p *Patch) file(path string) int {
+	for i, f := range p.Files {
+		if f.Path == path && f.Op != FileDelete {
+			return i
+		}
+	}
+	p.Files = append(p.Files, FileDiff{Path: path, OldPath: path})
+	return len(p.Files) - 1
+}
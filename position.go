@@ -0,0 +1,65 @@
+package fuzzypatch
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Position is a location in a document as a line and column.
+type Position struct {
+	Line   int // 1-based line
+	Column int // 1-based byte offset within the line, as in Diff.Column
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Range is the part of a document between two positions.
+type Range struct {
+	Start Position
+	End   Position // exclusive
+}
+
+// LineIndex converts byte offsets in a document to positions. It's worth
+// keeping one around to convert many offsets into the same document.
+type LineIndex struct {
+	starts []int // starts[i] is the offset of line i
+}
+
+// NewLineIndex returns the index of the lines in source.
+func NewLineIndex(source string) *LineIndex {
+	starts := []int{0}
+	for i := 0; ; {
+		j := strings.IndexByte(source[i:], '\n')
+		if j < 0 {
+			break
+		}
+		i += j + 1
+		starts = append(starts, i)
+	}
+	return &LineIndex{starts: starts}
+}
+
+// Position returns the position of a byte offset. An offset at the end of
+// a document that ends with a newline is at the start of the line after it.
+func (x *LineIndex) Position(offset int) Position {
+	i, found := slices.BinarySearch(x.starts, offset)
+	if !found {
+		i--
+	}
+	i = max(i, 0)
+	return Position{Line: i + 1, Column: offset - x.starts[i] + 1}
+}
+
+// Range returns the positions of the text an edit replaces.
+func (x *LineIndex) Range(e Edit) Range {
+	return Range{Start: x.Position(e.Start), End: x.Position(e.End)}
+}
+
+// Range returns the positions of the text the edit replaces in source.
+// Use a LineIndex to convert many edits to the same document.
+func (e Edit) Range(source string) Range {
+	return NewLineIndex(source).Range(e)
+}
@@ -0,0 +1,40 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestLineIndex(t *testing.T) {
+	x := NewLineIndex("ab\ncd\n\nef")
+	tests := []struct {
+		offset int
+		want   Position
+	}{
+		{offset: 0, want: Position{Line: 1, Column: 1}},
+		{offset: 2, want: Position{Line: 1, Column: 3}},
+		{offset: 3, want: Position{Line: 2, Column: 1}},
+		{offset: 5, want: Position{Line: 2, Column: 3}},
+		{offset: 6, want: Position{Line: 3, Column: 1}},
+		{offset: 7, want: Position{Line: 4, Column: 1}},
+		{offset: 9, want: Position{Line: 4, Column: 3}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, x.Position(tt.offset), tt.want, "offset %d", tt.offset)
+	}
+	assert.Equal(t, NewLineIndex("a\n").Position(2), Position{Line: 2, Column: 1})
+	assert.Equal(t, NewLineIndex("").Position(0), Position{Line: 1, Column: 1})
+}
+
+func TestEditRange(t *testing.T) {
+	source := "func f() {\n\treturn 1\n}\n"
+	edit, ok := Search(source, Diff{Search: "\treturn 1\n", Replace: "\treturn 2\n"})
+	assert.Assert(t, ok)
+	r := edit.Range(source)
+	assert.DeepEqual(t, r, Range{Start: Position{Line: 2, Column: 1}, End: Position{Line: 3, Column: 1}})
+	assert.Equal(t, r.Start.String(), "2:1")
+
+	r = Edit{Start: 19, End: 20, Text: "2"}.Range(source)
+	assert.DeepEqual(t, r, Range{Start: Position{Line: 2, Column: 9}, End: Position{Line: 2, Column: 10}})
+}
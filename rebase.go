@@ -0,0 +1,70 @@
+package fuzzypatch
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Rebase returns a copy of diffs with their line hints moved to where they
+// match source, so they keep applying cleanly as the document changes.
+// Nothing is applied. Insertions, EndOfFile hints, and diffs that don't
+// match keep their hints, and the error lists the diffs that didn't match.
+func Rebase(source string, diffs []Diff, opts ...SearchOption) ([]Diff, error) {
+	diffs = slices.Clone(diffs)
+	var errs []error
+	for i, d := range diffs {
+		if d.Search == "" {
+			continue
+		}
+		m, err := SearchMatch(source, d, opts...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("diff %d: %w", i+1, err))
+			continue
+		}
+		if d.Line != EndOfFile {
+			if d.LineEnd > 0 {
+				// keep the range the same size
+				d.LineEnd = max(d.LineEnd+m.Line-d.Line, m.LineEnd)
+			}
+			d.Line = m.Line
+		}
+		if d.Column > 0 {
+			bol := strings.LastIndexByte(source[:m.Edit.Start], '\n') + 1
+			if bol == 0 && strings.HasPrefix(source, bom) {
+				bol = len(bom)
+			}
+			d.Column = m.Edit.Start - bol + 1
+		}
+		diffs[i] = d
+	}
+	return diffs, errors.Join(errs...)
+}
+
+// RebasePatch is like Rebase, but for every file in a patch. The read
+// function returns the current content of a file, and new files are
+// rebased against empty content.
+func RebasePatch(patch Patch, read func(path string) (string, error), opts ...SearchOption) (Patch, error) {
+	rebased := Patch{Files: slices.Clone(patch.Files)}
+	var errs []error
+	for i, f := range rebased.Files {
+		var source string
+		switch f.Op {
+		case FileDelete:
+			continue
+		case FileModify, FileRename:
+			var err error
+			if source, err = read(f.OldPath); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		diffs, err := Rebase(source, f.Diffs, opts...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.Path, err))
+		}
+		rebased.Files[i].Diffs = diffs
+	}
+	return rebased, errors.Join(errs...)
+}
@@ -0,0 +1,55 @@
+package fuzzypatch
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestRebase(t *testing.T) {
+	source := "new\nlines\nfoo\nbar\nbaz\nx=1;x=2;\n"
+	diffs := []Diff{
+		{Line: 1, Search: "foo\n", Replace: "x\n"},
+		{Line: 2, LineEnd: 5, Search: "bar\nbaz\n", Replace: "y\n"},
+		{Line: 3, Replace: "inserted\n"},
+		{Line: 1, Search: "missing\n", Replace: "z\n"},
+		{Line: 1, Column: 1, Search: "x=2;\n", Replace: "x=3;\n"},
+		{Line: EndOfFile, Search: "baz\n", Replace: "qux\n"},
+	}
+	rebased, err := Rebase(source, diffs, WithMaxRadius(10), WithSubLine())
+	assert.ErrorIs(t, err, ErrNoMatch)
+	assert.Error(t, err, "diff 4: no match")
+	assert.DeepEqual(t, rebased, []Diff{
+		{Line: 3, Search: "foo\n", Replace: "x\n"},
+		{Line: 4, LineEnd: 7, Search: "bar\nbaz\n", Replace: "y\n"},
+		{Line: 3, Replace: "inserted\n"},
+		{Line: 1, Search: "missing\n", Replace: "z\n"},
+		{Line: 6, Column: 5, Search: "x=2;\n", Replace: "x=3;\n"},
+		{Line: EndOfFile, Search: "baz\n", Replace: "qux\n"},
+	})
+	assert.Equal(t, diffs[0].Line, 1, "the diffs are copied")
+}
+
+func TestRebasePatch(t *testing.T) {
+	files := map[string]string{"a.txt": "top\nfoo\n", "old.txt": "\n\nbar\n"}
+	read := func(path string) (string, error) {
+		if s, ok := files[path]; ok {
+			return s, nil
+		}
+		return "", fs.ErrNotExist
+	}
+	patch := Patch{Files: []FileDiff{
+		{Path: "a.txt", OldPath: "a.txt", Diffs: []Diff{{Line: 1, Search: "foo\n", Replace: "x\n"}}},
+		{Path: "new.txt", OldPath: "old.txt", Op: FileRename, Diffs: []Diff{{Line: 1, Search: "bar\n", Replace: "y\n"}}},
+		{Path: "c.txt", Op: FileCreate, Diffs: []Diff{{Replace: "new\n"}}},
+		{Path: "gone.txt", OldPath: "gone.txt", Diffs: []Diff{{Line: 1, Search: "z\n"}}},
+	}}
+	rebased, err := RebasePatch(patch, read)
+	assert.Assert(t, errors.Is(err, fs.ErrNotExist))
+	assert.Equal(t, rebased.Files[0].Diffs[0].Line, 2)
+	assert.Equal(t, rebased.Files[1].Diffs[0].Line, 3)
+	assert.DeepEqual(t, rebased.Files[2], patch.Files[2])
+	assert.Equal(t, patch.Files[0].Diffs[0].Line, 1)
+}
@@ -0,0 +1,89 @@
+package fuzzypatch
+
+import (
+	"regexp"
+	"slices"
+)
+
+// regexMatches returns the matches of the diff's regular expression that
+// fall within the searched lines, in document order. The expression is
+// compiled in multi-line mode, so ^ and $ match at line boundaries, and
+// the replace text can refer to submatches as in regexp.Regexp.Expand.
+func (s *searcher) regexMatches() ([]Match, error) {
+	re, err := regexp.Compile("(?m)" + s.diff.Search)
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := s.offsets[s.lo], s.offsets[s.hi]
+	var matches []Match
+	text := s.source[lo:hi]
+	for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+		start, end := lo+loc[0], lo+loc[1]
+		i, last := s.lineAt(start), s.lineAt(max(start, end-1))
+		if s.excluded(start, end) || !s.inRegion(i, last+1) || s.rejected(i, last+1) {
+			continue
+		}
+		m := s.expand(Match{
+			Edit: Edit{
+				Start: s.shift + start,
+				End:   s.shift + end,
+				Text:  string(re.ExpandString(nil, s.diff.Replace, text, loc)),
+			},
+			Score:    1,
+			Line:     i + 1,
+			LineEnd:  last + 1,
+			Distance: s.distance(i),
+		})
+		s.record(m, true)
+		matches = append(matches, m)
+	}
+	return matches, nil
+}
+
+// nearest filters matches to the ones within the maximum radius, or on the
+// hinted line in strict mode, and sorts them by their distance from the hint.
+func (s *searcher) nearest(matches []Match) []Match {
+	if r := s.maxRadius(); r > 0 {
+		matches = slices.DeleteFunc(matches, func(m Match) bool {
+			return m.Distance > r
+		})
+	}
+	if s.cfg.strictHint {
+		matches = slices.DeleteFunc(matches, func(m Match) bool {
+			return s.diff.Line == 0 || m.Distance > 0
+		})
+	}
+	slices.SortStableFunc(matches, s.compareDistance)
+	return matches
+}
+
+// regexMatch is SearchMatch for diffs with a regular expression.
+func (s *searcher) regexMatch() (Match, error) {
+	matches, err := s.regexMatches()
+	if err != nil {
+		return Match{}, err
+	}
+	if s.diff.Occurrence > 0 {
+		if s.diff.Occurrence > len(matches) {
+			return Match{}, s.noMatch()
+		}
+		return matches[s.diff.Occurrence-1], nil
+	}
+	matches = s.nearest(matches)
+	if len(matches) == 0 {
+		return Match{}, s.noMatch()
+	}
+	if s.cfg.ambiguity >= 0 && len(matches) > 1 {
+		return Match{}, &AmbiguousMatchError{Matches: matches}
+	}
+	return matches[0], nil
+}
+
+// lineAt returns the index of the line containing byte offset b.
+func (s *searcher) lineAt(b int) int {
+	i, found := slices.BinarySearch(s.offsets, b)
+	if !found {
+		i--
+	}
+	return min(i, max(0, len(s.lines)-1))
+}
@@ -0,0 +1,120 @@
+package fuzzypatch
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSearchRegex(t *testing.T) {
+	source := "name = \"app\"\nversion = \"1.2.3\"\n\n[deps]\nversion = \"0.4.0\"\n"
+	tests := []struct {
+		name string
+		diff Diff
+		opts []SearchOption
+		want Match
+		err  error
+	}{
+		{
+			name: "nearest to hint",
+			diff: Diff{Line: 5, Regex: true, Search: `^version = "[^"]*"`, Replace: `version = "0.5.0"`},
+			want: Match{Edit: Edit{Start: 39, End: 56, Text: `version = "0.5.0"`}, Score: 1, Line: 5, LineEnd: 5},
+		},
+		{
+			name: "first without hint",
+			diff: Diff{Regex: true, Search: `\d+\.\d+\.\d+`, Replace: "2.0.0"},
+			want: Match{Edit: Edit{Start: 24, End: 29, Text: "2.0.0"}, Score: 1, Line: 2, LineEnd: 2},
+		},
+		{
+			name: "multi-line",
+			diff: Diff{Line: 1, Regex: true, Search: "name = .*\nversion = .*\n", Replace: "name = \"x\"\n"},
+			want: Match{Edit: Edit{Start: 0, End: 31, Text: "name = \"x\"\n"}, Score: 1, Line: 1, LineEnd: 2},
+		},
+		{
+			name: "capture groups",
+			diff: Diff{Line: 2, Regex: true, Search: `^(\w+) = "(\d+)\.(\d+)\.\d+"`, Replace: `$1 = "$2.${3}.9"`},
+			want: Match{Edit: Edit{Start: 13, End: 30, Text: `version = "1.2.9"`}, Score: 1, Line: 2, LineEnd: 2},
+		},
+		{
+			name: "named capture groups",
+			diff: Diff{Line: 1, Regex: true, Search: `^name = "(?P<name>\w+)"`, Replace: `name = "${name}-v2" # $$HOME`},
+			want: Match{Edit: Edit{Start: 0, End: 12, Text: `name = "app-v2" # $HOME`}, Score: 1, Line: 1, LineEnd: 1},
+		},
+		{
+			name: "occurrence",
+			diff: Diff{Occurrence: 2, Regex: true, Search: `"\d[^"]*"`, Replace: `"9"`},
+			want: Match{Edit: Edit{Start: 49, End: 56, Text: `"9"`}, Score: 1, Line: 5, LineEnd: 5},
+		},
+		{
+			name: "line range",
+			diff: Diff{Line: 4, LineEnd: 5, Regex: true, Search: `version`, Replace: "v"},
+			want: Match{Edit: Edit{Start: 39, End: 46, Text: "v"}, Score: 1, Line: 5, LineEnd: 5, Distance: 1},
+		},
+		{
+			name: "column hint",
+			diff: Diff{Line: 2, Column: 16, Regex: true, Search: `\d`, Replace: "9"},
+			want: Match{Edit: Edit{Start: 28, End: 29, Text: "9"}, Score: 1, Line: 2, LineEnd: 2},
+		},
+		{
+			name: "equal column distance",
+			diff: Diff{Line: 2, Column: 13, Regex: true, Search: `\d`, Replace: "9"},
+			want: Match{Edit: Edit{Start: 24, End: 25, Text: "9"}, Score: 1, Line: 2, LineEnd: 2},
+		},
+		{
+			name: "column hint on another line",
+			diff: Diff{Line: 3, Column: 1, Regex: true, Search: `version`, Replace: "v"},
+			want: Match{Edit: Edit{Start: 13, End: 20, Text: "v"}, Score: 1, Line: 2, LineEnd: 2, Distance: 1},
+		},
+		{
+			name: "beyond max radius",
+			diff: Diff{Line: 1, Regex: true, Search: `deps`, Replace: "x"},
+			opts: []SearchOption{WithMaxRadius(2)},
+			err:  ErrNoMatch,
+		},
+		{
+			name: "ambiguous",
+			diff: Diff{Regex: true, Search: `version`, Replace: "v"},
+			opts: []SearchOption{WithAmbiguityCheck(0)},
+			err:  ErrAmbiguousMatch,
+		},
+		{
+			name: "no match",
+			diff: Diff{Regex: true, Search: `missing`, Replace: "x"},
+			err:  ErrNoMatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := SearchMatch(source, tt.diff, tt.opts...)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, m, tt.want)
+		})
+	}
+}
+
+func TestSearchRegexInvalid(t *testing.T) {
+	_, err := SearchMatch("foo\n", Diff{Regex: true, Search: "(", Replace: "x"})
+	assert.ErrorContains(t, err, "missing closing )")
+	_, ok := Search("foo\n", Diff{Regex: true, Search: "(", Replace: "x"})
+	assert.Assert(t, !ok)
+}
+
+func TestSearchRegexSameLine(t *testing.T) {
+	source := strings.Repeat(strings.Repeat("x ", 10)+"\n", 5)
+	diff := Diff{Line: 3, Regex: true, Search: `x`, Replace: "y"}
+	m, err := SearchMatch(source, diff)
+	assert.NilError(t, err)
+	assert.Equal(t, m.Edit.Start, 42)
+
+	// matches at the same distance stay in document order
+	matches := SearchAll(source, diff)
+	assert.Equal(t, len(matches), 50)
+	for i, m := range matches[:10] {
+		assert.Equal(t, m.Edit.Start, 42+2*i)
+	}
+}
@@ -0,0 +1,283 @@
+package fuzzypatch
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ApplyOption configures how edits are applied.
+type ApplyOption func(*applyConfig)
+
+type applyConfig struct {
+	overlap OverlapPolicy
+}
+
+func newApplyConfig(opts []ApplyOption) *applyConfig {
+	cfg := &applyConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// ErrOverlap is the cause of the error returned for overlapping edits.
+var ErrOverlap = errors.New("overlapping edits")
+
+// OverlapPolicy decides what happens to an edit that overlaps one that comes
+// before it in the list of edits.
+type OverlapPolicy int
+
+const (
+	OverlapError OverlapPolicy = iota // fail without applying any of the edits
+	OverlapSkip                       // skip the later edit
+	OverlapMerge                      // merge the edits if they're compatible, and fail otherwise
+)
+
+// WithOverlap sets the policy for overlapping edits. The default is
+// OverlapError. OverlapMerge treats edits as compatible when they make the
+// same change, such as a hunk that was repeated, or when they no longer
+// overlap once they're trimmed to the lines that change, as by TrimEdit.
+func WithOverlap(policy OverlapPolicy) ApplyOption {
+	return func(cfg *applyConfig) {
+		cfg.overlap = policy
+	}
+}
+
+// EditResult describes what ApplyReport did with an edit.
+type EditResult struct {
+	Edit       Edit
+	Start      int  // Byte offset of the edit's text in the output
+	End        int  // Byte offset where the edit's text ends in the output (exclusive)
+	Added      int  // Number of bytes it inserted, after any trimming
+	Removed    int  // Number of bytes it removed, after any trimming
+	Applied    bool // Whether the edit was applied
+	Overlapped bool // Whether it overlapped an earlier edit, and was skipped or merged
+}
+
+// ApplyReport is like Apply, but it also reports where each edit's text
+// ended up in the output, in the same order as the edits. Edits that start
+// at the same offset are applied in the order they're given, after any
+// insertions there. When it returns an error, none of the edits are applied.
+func ApplyReport(source string, edits []Edit, opts ...ApplyOption) (string, []EditResult, error) {
+	out, p, err := applyPlan(source, edits, opts)
+	return out, p.results, err
+}
+
+// applyPlan plans the edits and applies them, placing each one.
+func applyPlan(source string, edits []Edit, opts []ApplyOption) (string, *editPlan, error) {
+	p, err := planEdits(source, edits, newApplyConfig(opts))
+	if err != nil {
+		return "", p, err
+	}
+	var b strings.Builder
+	b.Grow(p.size)
+	last := 0 // end of the previous edit in the source
+	for _, i := range p.order {
+		e := p.edits[i]
+		b.WriteString(source[last:e.Start])
+		p.place(i, b.Len())
+		b.WriteString(e.Text)
+		last = e.End
+	}
+	b.WriteString(source[last:])
+	p.finish()
+	return b.String(), p, nil
+}
+
+// editPlan is the order that edits are applied in, and how they're changed
+// to resolve overlaps.
+type editPlan struct {
+	edits   []Edit      // the edits to apply, which may have been trimmed to merge them
+	order   []int       // indexes of the edits to apply, in the order they're applied
+	same    map[int]int // edits that make the same change as an earlier one
+	results []EditResult
+	size    int // length of the output
+}
+
+// planEdits checks the edits and resolves their overlaps. The plan's results
+// are returned even when there's an error.
+func planEdits(source string, edits []Edit, cfg *applyConfig) (*editPlan, error) {
+	p := &editPlan{edits: slices.Clone(edits), same: map[int]int{}, results: make([]EditResult, len(edits))}
+	for i, e := range edits {
+		p.results[i] = EditResult{Edit: e}
+		if e.Start < 0 || e.End < e.Start || e.End > len(source) {
+			return p, fmt.Errorf("invalid edit range [%d,%d)", e.Start, e.End)
+		}
+	}
+	if cfg.overlap == OverlapMerge {
+		if err := p.merge(source); err != nil {
+			return p, err
+		}
+	} else if err := p.resolve(cfg.overlap); err != nil {
+		return p, err
+	}
+	p.size = len(source)
+	for _, i := range p.order {
+		p.size += len(p.edits[i].Text) - (p.edits[i].End - p.edits[i].Start)
+	}
+	return p, nil
+}
+
+// resolve adds the edits to the order, skipping or failing on the ones that
+// overlap an earlier edit. When no two edits overlap, that's all it takes to
+// sort them. Otherwise, each edit is checked against the edits before it
+// that start before it ends.
+func (p *editPlan) resolve(policy OverlapPolicy) error {
+	sorted := make([]int, len(p.edits))
+	for i := range sorted {
+		sorted[i] = i
+	}
+	slices.SortStableFunc(sorted, func(a, b int) int {
+		return compareEdits(p.edits[a], p.edits[b])
+	})
+	disjoint := true
+	for k := 1; k < len(sorted) && disjoint; k++ {
+		disjoint = !overlaps(p.edits[sorted[k-1]], p.edits[sorted[k]])
+	}
+	if disjoint {
+		p.order = sorted
+		return nil
+	}
+	rank := make([]int, len(sorted))
+	for r, i := range sorted {
+		rank[i] = r
+	}
+	ends := newMaxTree(len(sorted)) // the ends of the applied edits, by rank
+	for i, e := range p.edits {
+		// the number of edits that start before e ends
+		n, _ := slices.BinarySearchFunc(sorted, e.End, func(j, end int) int {
+			return cmp.Compare(p.edits[j].Start, end)
+		})
+		if ends.max(n) > e.Start {
+			if policy == OverlapSkip {
+				p.results[i].Overlapped = true
+				continue
+			}
+			return p.overlapError(i)
+		}
+		ends.set(rank[i], e.End)
+	}
+	p.order = slices.DeleteFunc(sorted, func(i int) bool {
+		return p.results[i].Overlapped
+	})
+	return nil
+}
+
+// merge adds the edits to the order, merging the ones that overlap an earlier
+// edit when they're compatible.
+func (p *editPlan) merge(source string) error {
+	applied := p.edits
+	for i := range applied {
+		j := slices.IndexFunc(p.order, func(j int) bool { return overlaps(applied[j], applied[i]) })
+		if j < 0 {
+			p.order = append(p.order, i)
+			continue
+		}
+		j = p.order[j]
+		if sameChange(source, applied[j], applied[i]) {
+			p.results[i].Overlapped, p.results[i].Applied = true, true
+			p.same[i] = j
+			continue
+		}
+		a, b := TrimEdit(source, applied[j]), TrimEdit(source, applied[i])
+		fits := !slices.ContainsFunc(p.order, func(k int) bool {
+			return k != j && overlaps(applied[k], b)
+		})
+		if !overlaps(a, b) && fits {
+			applied[j], applied[i] = a, b
+			p.results[i].Overlapped = true
+			p.order = append(p.order, i)
+			continue
+		}
+		return p.overlapError(i)
+	}
+	slices.SortStableFunc(p.order, func(a, b int) int {
+		return compareEdits(applied[a], applied[b])
+	})
+	return nil
+}
+
+// overlapError returns the error for edit i overlapping an earlier edit, and
+// marks all of the edits as not applied.
+func (p *editPlan) overlapError(i int) error {
+	e := p.results[i].Edit
+	p.results = notApplied(p.results)
+	return fmt.Errorf("%w at [%d,%d)", ErrOverlap, e.Start, e.End)
+}
+
+// place records that edit i's text starts at offset start in the output.
+func (p *editPlan) place(i, start int) {
+	e := p.edits[i]
+	r := &p.results[i]
+	r.Start, r.End = start, start+len(e.Text)
+	r.Added, r.Removed = len(e.Text), e.End-e.Start
+	r.Applied = true
+}
+
+// finish fills in the results of the duplicate edits, once all of the edits
+// have been placed.
+func (p *editPlan) finish() {
+	for i, j := range p.same {
+		p.results[i].Start, p.results[i].End = p.results[j].Start, p.results[j].End
+	}
+}
+
+// overlaps reports whether the edits replace any of the same bytes, or one
+// inserts text inside of the other.
+func overlaps(a, b Edit) bool {
+	return a.Start < b.End && b.Start < a.End
+}
+
+// compareEdits orders edits by where they start, and then by where they end,
+// so insertions come before the edits that replace text at the same offset.
+func compareEdits(a, b Edit) int {
+	return cmp.Or(a.Start-b.Start, a.End-b.End)
+}
+
+// maxTree is a Fenwick tree for the maximum of a prefix of values.
+type maxTree []int
+
+func newMaxTree(n int) maxTree {
+	t := make(maxTree, n+1)
+	for i := range t {
+		t[i] = -1
+	}
+	return t
+}
+
+// set raises the value at index i to v.
+func (t maxTree) set(i, v int) {
+	for i++; i < len(t); i += i & -i {
+		t[i] = max(t[i], v)
+	}
+}
+
+// max returns the largest value at an index below n, or -1 if there is none.
+func (t maxTree) max(n int) int {
+	m := -1
+	for ; n > 0; n -= n & -n {
+		m = max(m, t[n])
+	}
+	return m
+}
+
+// sameChange reports whether applying either edit to source gives the same
+// result.
+func sameChange(source string, a, b Edit) bool {
+	start, end := min(a.Start, b.Start), max(a.End, b.End)
+	apply := func(e Edit) string {
+		return source[start:e.Start] + e.Text + source[e.End:end]
+	}
+	return apply(a) == apply(b)
+}
+
+// notApplied marks all of the results as not applied.
+func notApplied(results []EditResult) []EditResult {
+	for i := range results {
+		results[i] = EditResult{Edit: results[i].Edit}
+	}
+	return results
+}
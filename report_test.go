@@ -0,0 +1,172 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestApplyReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		edits   []Edit
+		want    string
+		results []EditResult
+		err     bool
+	}{
+		{
+			name:   "no edits",
+			source: "hello\n",
+			want:   "hello\n",
+		},
+		{
+			name:   "out of order",
+			source: "foo bar baz\n",
+			edits: []Edit{
+				{Start: 8, End: 11, Text: "qux"},
+				{Start: 0, End: 3, Text: "f"},
+			},
+			want: "f bar qux\n",
+			results: []EditResult{
+				{Edit: Edit{Start: 8, End: 11, Text: "qux"}, Start: 6, End: 9, Added: 3, Removed: 3, Applied: true},
+				{Edit: Edit{Start: 0, End: 3, Text: "f"}, Start: 0, End: 1, Added: 1, Removed: 3, Applied: true},
+			},
+		},
+		{
+			name:   "insertions at the same offset",
+			source: "ac",
+			edits: []Edit{
+				{Start: 1, End: 1, Text: "b"},
+				{Start: 1, End: 1, Text: "B"},
+			},
+			want: "abBc",
+			results: []EditResult{
+				{Edit: Edit{Start: 1, End: 1, Text: "b"}, Start: 1, End: 2, Added: 1, Applied: true},
+				{Edit: Edit{Start: 1, End: 1, Text: "B"}, Start: 2, End: 3, Added: 1, Applied: true},
+			},
+		},
+		{
+			name:   "deletion",
+			source: "abc",
+			edits:  []Edit{{Start: 1, End: 2}},
+			want:   "ac",
+			results: []EditResult{
+				{Edit: Edit{Start: 1, End: 2}, Start: 1, End: 1, Removed: 1, Applied: true},
+			},
+		},
+		{
+			name:   "overlapping",
+			source: "abcdef",
+			edits: []Edit{
+				{Start: 0, End: 3, Text: "x"},
+				{Start: 2, End: 4, Text: "y"},
+			},
+			results: []EditResult{
+				{Edit: Edit{Start: 0, End: 3, Text: "x"}},
+				{Edit: Edit{Start: 2, End: 4, Text: "y"}},
+			},
+			err: true,
+		},
+		{
+			name:   "invalid range",
+			source: "abc",
+			edits:  []Edit{{Start: 2, End: 9}},
+			results: []EditResult{
+				{Edit: Edit{Start: 2, End: 9}},
+			},
+			err: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, results, err := ApplyReport(tt.source, tt.edits)
+			if tt.err {
+				assert.Assert(t, err != nil)
+			} else {
+				assert.NilError(t, err)
+				assert.Equal(t, got, tt.want)
+			}
+			if len(tt.edits) > 0 {
+				assert.DeepEqual(t, results, tt.results)
+			}
+			for _, r := range results {
+				if r.Applied {
+					assert.Equal(t, got[r.Start:r.End], r.Edit.Text)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyOverlap(t *testing.T) {
+	source := "a\nb\nc\nd\n"
+	tests := []struct {
+		name    string
+		policy  OverlapPolicy
+		edits   []Edit
+		want    string
+		results []EditResult
+		err     bool
+	}{
+		{
+			name:   "error",
+			policy: OverlapError,
+			edits:  []Edit{{Start: 0, End: 4, Text: "A\nb\n"}, {Start: 2, End: 6, Text: "b\nC\n"}},
+			err:    true,
+		},
+		{
+			name:   "skip",
+			policy: OverlapSkip,
+			edits:  []Edit{{Start: 0, End: 4, Text: "A\nB\n"}, {Start: 2, End: 6, Text: "x\n"}, {Start: 6, End: 8, Text: "D\n"}},
+			want:   "A\nB\nc\nD\n",
+			results: []EditResult{
+				{Edit: Edit{Start: 0, End: 4, Text: "A\nB\n"}, Start: 0, End: 4, Added: 4, Removed: 4, Applied: true},
+				{Edit: Edit{Start: 2, End: 6, Text: "x\n"}, Overlapped: true},
+				{Edit: Edit{Start: 6, End: 8, Text: "D\n"}, Start: 6, End: 8, Added: 2, Removed: 2, Applied: true},
+			},
+		},
+		{
+			name:   "merge duplicates",
+			policy: OverlapMerge,
+			edits:  []Edit{{Start: 2, End: 4, Text: "B\n"}, {Start: 2, End: 4, Text: "B\n"}},
+			want:   "a\nB\nc\nd\n",
+			results: []EditResult{
+				{Edit: Edit{Start: 2, End: 4, Text: "B\n"}, Start: 2, End: 4, Added: 2, Removed: 2, Applied: true},
+				{Edit: Edit{Start: 2, End: 4, Text: "B\n"}, Start: 2, End: 4, Applied: true, Overlapped: true},
+			},
+		},
+		{
+			name:   "merge trimmed",
+			policy: OverlapMerge,
+			edits:  []Edit{{Start: 0, End: 4, Text: "A\nb\n"}, {Start: 2, End: 6, Text: "b\nC\n"}},
+			want:   "A\nb\nC\nd\n",
+			results: []EditResult{
+				{Edit: Edit{Start: 0, End: 4, Text: "A\nb\n"}, Start: 0, End: 2, Added: 2, Removed: 2, Applied: true},
+				{Edit: Edit{Start: 2, End: 6, Text: "b\nC\n"}, Start: 4, End: 6, Added: 2, Removed: 2, Applied: true, Overlapped: true},
+			},
+		},
+		{
+			name:   "merge conflict",
+			policy: OverlapMerge,
+			edits:  []Edit{{Start: 2, End: 4, Text: "B\n"}, {Start: 2, End: 4, Text: "X\n"}},
+			err:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, results, err := ApplyReport(source, tt.edits, WithOverlap(tt.policy))
+			if tt.err {
+				assert.ErrorContains(t, err, "overlapping edits")
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, got, tt.want)
+			assert.DeepEqual(t, results, tt.results)
+
+			got, err = Apply(source, tt.edits, WithOverlap(tt.policy))
+			assert.NilError(t, err)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package fuzzypatch
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrIrreversible is the cause of the error Reverse returns for diffs that
+// can't be undone, such as Regex diffs.
+var ErrIrreversible = errors.New("irreversible diff")
+
+// ReverseEdits returns the edits that undo applying edits to source, in the
+// same order. Their offsets refer to the output of Apply.
+func ReverseEdits(source string, edits []Edit) ([]Edit, error) {
+	_, results, err := ApplyReport(source, edits)
+	if err != nil {
+		return nil, err
+	}
+	reversed := make([]Edit, len(results))
+	for i, r := range results {
+		reversed[i] = Edit{Start: r.Start, End: r.End, Text: source[r.Edit.Start:r.Edit.End]}
+	}
+	return reversed, nil
+}
+
+// Reverse returns diffs that undo the given diffs, by swapping their search
+// and replace text. Context lines are kept on both sides of the reversed
+// search text, and Reject is dropped, since the text it guards against is
+// usually what the diff added. Regex diffs can't be reversed.
+func Reverse(diffs []Diff) ([]Diff, error) {
+	reversed := make([]Diff, len(diffs))
+	for i, d := range diffs {
+		if d.Regex {
+			return nil, fmt.Errorf("diff %d uses a regular expression: %w", i+1, ErrIrreversible)
+		}
+		lines := trimSplit(d.Search)
+		before := min(d.ContextBefore, len(lines))
+		after := min(d.ContextAfter, len(lines)-before)
+		head := strings.Join(lines[:before], "")
+		tail := strings.Join(lines[len(lines)-after:], "")
+		d.Search, d.Replace = head+d.Replace+tail, strings.Join(lines[before:len(lines)-after], "")
+		d.Reject, d.RejectRadius = nil, 0
+		reversed[i] = d
+	}
+	return reversed, nil
+}
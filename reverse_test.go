@@ -0,0 +1,88 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestReverseEdits(t *testing.T) {
+	source := "foo bar baz\n"
+	edits := []Edit{{Start: 8, End: 11, Text: "quux"}, {Start: 0, End: 3, Text: ""}, {Start: 4, End: 4, Text: "new "}}
+	patched, err := Apply(source, edits)
+	assert.NilError(t, err)
+	assert.Equal(t, patched, " new bar quux\n")
+
+	reversed, err := ReverseEdits(source, edits)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, reversed, []Edit{{Start: 9, End: 13, Text: "baz"}, {Start: 0, End: 0, Text: "foo"}, {Start: 1, End: 5, Text: ""}})
+	restored, err := Apply(patched, reversed)
+	assert.NilError(t, err)
+	assert.Equal(t, restored, source)
+
+	_, err = ReverseEdits(source, []Edit{{Start: 0, End: 20}})
+	assert.ErrorContains(t, err, "invalid edit range")
+}
+
+func TestReverse(t *testing.T) {
+	tests := []struct {
+		name string
+		diff Diff
+		want Diff
+		err  bool
+	}{
+		{
+			name: "replacement",
+			diff: Diff{Line: 3, Search: "a\n", Replace: "b\n"},
+			want: Diff{Line: 3, Search: "b\n", Replace: "a\n"},
+		},
+		{
+			name: "insertion",
+			diff: Diff{Line: 2, Replace: "new\n"},
+			want: Diff{Line: 2, Search: "new\n"},
+		},
+		{
+			name: "context lines",
+			diff: Diff{Search: "func f() {\n\treturn 1\n}\n", Replace: "\treturn 2\n", ContextBefore: 1, ContextAfter: 1},
+			want: Diff{Search: "func f() {\n\treturn 2\n}\n", Replace: "\treturn 1\n", ContextBefore: 1, ContextAfter: 1},
+		},
+		{
+			name: "reject",
+			diff: Diff{Search: "x++\n", Replace: "mu.Lock()\nx++\n", Reject: []string{"mu.Lock()"}, RejectRadius: 1},
+			want: Diff{Search: "mu.Lock()\nx++\n", Replace: "x++\n"},
+		},
+		{
+			name: "regex",
+			diff: Diff{Regex: true, Search: "v\\d+\n", Replace: "v2\n"},
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Reverse([]Diff{tt.diff})
+			if tt.err {
+				assert.ErrorIs(t, err, ErrIrreversible)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, []Diff{tt.want})
+		})
+	}
+}
+
+func TestReverseRoundTrip(t *testing.T) {
+	source := "func f() {\n\treturn 1\n}\n"
+	diff := Diff{Line: 1, Search: "func f() {\n\treturn 1\n}\n", Replace: "\treturn 2\n", ContextBefore: 1, ContextAfter: 1}
+	edit, ok := Search(source, diff)
+	assert.Assert(t, ok)
+	patched, err := Apply(source, []Edit{edit})
+	assert.NilError(t, err)
+
+	reversed, err := Reverse([]Diff{diff})
+	assert.NilError(t, err)
+	edit, ok = Search(patched, reversed[0])
+	assert.Assert(t, ok)
+	restored, err := Apply(patched, []Edit{edit})
+	assert.NilError(t, err)
+	assert.Equal(t, restored, source)
+}
@@ -0,0 +1,79 @@
+package fuzzypatch
+
+import "sort"
+
+// SourceMap translates offsets and lines between a document and the output
+// of applying edits to it, so positions such as diagnostics and cursors stay
+// on the same text after a patch.
+type SourceMap struct {
+	spans  []mappedSpan // the applied edits, in order
+	source *LineIndex
+	output *LineIndex
+}
+
+// mappedSpan is the range an edit replaced in the source, and the range of
+// its text in the output.
+type mappedSpan struct {
+	src, out [2]int
+}
+
+// ApplyMap is like Apply, but it also returns a SourceMap between source and
+// the output.
+func ApplyMap(source string, edits []Edit, opts ...ApplyOption) (string, *SourceMap, error) {
+	out, p, err := applyPlan(source, edits, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	m := &SourceMap{source: NewLineIndex(source), output: NewLineIndex(out)}
+	for _, i := range p.order {
+		e, r := p.edits[i], p.results[i]
+		m.spans = append(m.spans, mappedSpan{src: [2]int{e.Start, e.End}, out: [2]int{r.Start, r.End}})
+	}
+	return out, m, nil
+}
+
+// ToOutput returns the offset in the output of an offset in the source.
+// Offsets in text that an edit replaced map to the start of the edit's
+// text, and offsets where text was inserted map to the end of it.
+func (m *SourceMap) ToOutput(offset int) int {
+	return m.convert(offset, func(s mappedSpan) [2]int { return s.src }, func(s mappedSpan) [2]int { return s.out })
+}
+
+// ToSource returns the offset in the source of an offset in the output.
+// Offsets in the text of an edit map to the start of the text it replaced.
+func (m *SourceMap) ToSource(offset int) int {
+	return m.convert(offset, func(s mappedSpan) [2]int { return s.out }, func(s mappedSpan) [2]int { return s.src })
+}
+
+// LineToOutput returns the 1-based line in the output that a 1-based line of
+// the source ended up on.
+func (m *SourceMap) LineToOutput(line int) int {
+	return m.output.Position(m.ToOutput(m.source.lineStart(line))).Line
+}
+
+// LineToSource returns the 1-based line in the source that a 1-based line of
+// the output came from.
+func (m *SourceMap) LineToSource(line int) int {
+	return m.source.Position(m.ToSource(m.output.lineStart(line))).Line
+}
+
+// convert maps an offset using the from and to ranges of the spans.
+func (m *SourceMap) convert(offset int, from, to func(mappedSpan) [2]int) int {
+	// the first span that ends after the offset
+	k := sort.Search(len(m.spans), func(i int) bool {
+		return from(m.spans[i])[1] > offset
+	})
+	if k < len(m.spans) && from(m.spans[k])[0] <= offset {
+		return to(m.spans[k])[0]
+	}
+	if k == 0 {
+		return offset
+	}
+	prev := m.spans[k-1]
+	return to(prev)[1] + offset - from(prev)[1]
+}
+
+// lineStart returns the offset of a 1-based line, clamped to the document.
+func (x *LineIndex) lineStart(line int) int {
+	return x.starts[min(max(line, 1), len(x.starts))-1]
+}
@@ -0,0 +1,52 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSourceMap(t *testing.T) {
+	source := "a\nb\nc\nd\n"
+	edits := []Edit{
+		{Start: 2, End: 4, Text: "B1\nB2\n"}, // b → two lines
+		{Start: 6, End: 6, Text: "new\n"},    // insert before d
+		{Start: 4, End: 6},                   // delete c
+	}
+	out, m, err := ApplyMap(source, edits)
+	assert.NilError(t, err)
+	assert.Equal(t, out, "a\nB1\nB2\nnew\nd\n")
+
+	offsets := []struct {
+		name   string
+		source int
+		output int
+	}{
+		{name: "before the edits", source: 1, output: 1},
+		{name: "start of a replacement", source: 2, output: 2},
+		{name: "inside a replacement", source: 3, output: 2},
+		{name: "inside a deletion", source: 5, output: 8},
+		{name: "after an insertion", source: 6, output: 12},
+		{name: "end", source: 8, output: 14},
+	}
+	for _, tt := range offsets {
+		assert.Equal(t, m.ToOutput(tt.source), tt.output, tt.name)
+	}
+	assert.Equal(t, m.ToSource(1), 1)
+	assert.Equal(t, m.ToSource(5), 2) // inside the replacement text
+	assert.Equal(t, m.ToSource(9), 6) // inside the inserted text
+	assert.Equal(t, m.ToSource(12), 6)
+	assert.Equal(t, m.ToSource(14), 8)
+
+	lines := []struct{ source, output int }{{1, 1}, {2, 2}, {3, 4}, {4, 5}, {5, 6}}
+	for _, tt := range lines {
+		assert.Equal(t, m.LineToOutput(tt.source), tt.output, "source line %d", tt.source)
+	}
+	assert.Equal(t, m.LineToSource(1), 1)
+	assert.Equal(t, m.LineToSource(3), 2)
+	assert.Equal(t, m.LineToSource(4), 4)
+	assert.Equal(t, m.LineToSource(5), 4)
+
+	_, _, err = ApplyMap(source, []Edit{{Start: 0, End: 3}, {Start: 2, End: 4}})
+	assert.ErrorIs(t, err, ErrOverlap)
+}
@@ -0,0 +1,203 @@
+package fuzzypatch
+
+import (
+	"bufio"
+	"errors"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// lineBreaks is the set of characters that ScanUniversalLines ends lines at.
+const lineBreaks = "\r\n\u2028\u2029"
+
+// ScanUniversalLines is a bufio.SplitFunc that ends lines at "\n", "\r\n",
+// a lone "\r" as in old Mac files, and the Unicode line and paragraph
+// separators U+2028 and U+2029. Unlike bufio.ScanLines, the line breaks are
+// kept.
+func ScanUniversalLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		switch {
+		case r == '\r':
+			if i+1 == len(data) && !atEOF {
+				return 0, nil, nil // it might be the start of a "\r\n"
+			}
+			if i+1 < len(data) && data[i+1] == '\n' {
+				size++
+			}
+			fallthrough
+		case r == '\n' || r == '\u2028' || r == '\u2029':
+			return i + size, data[:i+size], nil
+		case r == utf8.RuneError && !atEOF && !utf8.FullRune(data[i:]):
+			return 0, nil, nil // a separator might be split across reads
+		}
+		i += size
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// WithLineSplit ends the lines of the source and the diff where split says
+// they end, instead of only after "\n". The split function must keep the
+// line breaks in its tokens, as ScanUniversalLines does. The text is searched
+// as if every line ended with "\n", and the replace text is given the line
+// break of the source's first line.
+func WithLineSplit(split bufio.SplitFunc) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.split = split
+	}
+}
+
+// WithPatchLineSplit is like WithLineSplit, but for the lines of a patch.
+func WithPatchLineSplit(split bufio.SplitFunc) ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.split = split
+	}
+}
+
+// splitLines returns the tokens of s produced by split.
+func splitLines(s string, split bufio.SplitFunc) []string {
+	var lines []string
+	data := []byte(s)
+	for len(data) > 0 {
+		n, token, err := split(data, true)
+		if err != nil && !errors.Is(err, bufio.ErrFinalToken) {
+			break
+		}
+		if n <= 0 {
+			// the split function has to consume the rest at EOF
+			n = len(data)
+			token = data
+		}
+		if token != nil {
+			lines = append(lines, string(token))
+		}
+		data = data[n:]
+		if err != nil {
+			break
+		}
+	}
+	return lines
+}
+
+// lineBreak returns the line break at the end of line, if it has one.
+func lineBreak(line string) string {
+	if strings.HasSuffix(line, "\r\n") {
+		return "\r\n"
+	}
+	r, size := utf8.DecodeLastRuneInString(line)
+	if size > 0 && strings.ContainsRune(lineBreaks, r) {
+		return line[len(line)-size:]
+	}
+	return ""
+}
+
+// translation maps between a source split with a custom split function, and
+// the same source with every line break replaced by "\n".
+type translation struct {
+	source  string // the translated source
+	eol     string // the line break of the original source
+	split   bufio.SplitFunc
+	offsets []int // offsets[i] is the start byte of line i in the original
+	shadow  []int // shadow[i] is the start byte of line i in the translation
+	texts   []int // texts[i] is the length of line i without its line break
+}
+
+func newTranslation(source string, split bufio.SplitFunc) *translation {
+	t := &translation{split: split, eol: "\n", offsets: []int{0}, shadow: []int{0}}
+	lines := splitLines(source, split)
+	if len(lines) > 0 {
+		if eol := lineBreak(lines[0]); eol != "" {
+			t.eol = eol
+		}
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(toNewline(line))
+		t.offsets = append(t.offsets, t.offsets[len(t.offsets)-1]+len(line))
+		t.shadow = append(t.shadow, b.Len())
+		t.texts = append(t.texts, len(line)-len(lineBreak(line)))
+	}
+	t.source = b.String()
+	return t
+}
+
+// toNewline replaces the line break at the end of line with "\n".
+func toNewline(line string) string {
+	if eol := lineBreak(line); eol != "" {
+		return strings.TrimSuffix(line, eol) + "\n"
+	}
+	return line
+}
+
+// text returns s split into lines, with every line break replaced by "\n".
+func (t *translation) text(s string) string {
+	var b strings.Builder
+	for _, line := range splitLines(s, t.split) {
+		b.WriteString(toNewline(line))
+	}
+	return b.String()
+}
+
+// diff returns the diff with its text translated.
+func (t *translation) diff(diff Diff) Diff {
+	diff.Search = t.text(diff.Search)
+	diff.Replace = t.text(diff.Replace)
+	return diff
+}
+
+// option returns a SearchOption that searches the translated source, with
+// the byte offsets given to earlier options translated too.
+func (t *translation) option() SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.split = nil
+		if cfg.byteRange[1] > 0 {
+			cfg.byteRange = [2]int{t.convert(cfg.byteRange[0], t.offsets, t.shadow), t.convert(cfg.byteRange[1], t.offsets, t.shadow)}
+		}
+		cfg.exclude = slices.Clone(cfg.exclude)
+		for i, e := range cfg.exclude {
+			cfg.exclude[i].Start = t.convert(e.Start, t.offsets, t.shadow)
+			cfg.exclude[i].End = t.convert(e.End, t.offsets, t.shadow)
+		}
+	}
+}
+
+// convert maps byte offset b between sources with the given line offsets.
+// Offsets within a line break map to the end of the line's text.
+func (t *translation) convert(b int, from, to []int) int {
+	i, found := slices.BinarySearch(from, b)
+	if found {
+		return to[i]
+	}
+	if i >= len(from) {
+		return to[len(to)-1]
+	}
+	i--
+	return to[i] + min(b-from[i], t.texts[i])
+}
+
+// match returns m with its edit mapped back to the original source.
+func (t *translation) match(m Match) Match {
+	m.Edit.Start = t.convert(m.Edit.Start, t.shadow, t.offsets)
+	m.Edit.End = t.convert(m.Edit.End, t.shadow, t.offsets)
+	if t.eol != "\n" {
+		m.Edit.Text = withLineEnding(m.Edit.Text, t.eol)
+	}
+	return m
+}
+
+// err returns err with the matches it lists mapped back to the original.
+func (t *translation) err(err error) error {
+	var aerr *AmbiguousMatchError
+	if !errors.As(err, &aerr) {
+		return err
+	}
+	matches := make([]Match, len(aerr.Matches))
+	for i, m := range aerr.Matches {
+		matches[i] = t.match(m)
+	}
+	return &AmbiguousMatchError{Matches: matches}
+}
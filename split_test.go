@@ -0,0 +1,125 @@
+package fuzzypatch
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestScanUniversalLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "empty", input: "", want: nil},
+		{name: "newline", input: "a\nb\n", want: []string{"a\n", "b\n"}},
+		{name: "crlf", input: "a\r\nb\r\n", want: []string{"a\r\n", "b\r\n"}},
+		{name: "carriage return", input: "a\rb\r", want: []string{"a\r", "b\r"}},
+		{name: "separators", input: "a\u2028b\u2029c", want: []string{"a\u2028", "b\u2029", "c"}},
+		{name: "mixed", input: "a\r\r\nb\n\rc", want: []string{"a\r", "\r\n", "b\n", "\r", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.DeepEqual(t, splitLines(tt.input, ScanUniversalLines), tt.want)
+
+			// reading a byte at a time mustn't split "\r\n" or a separator
+			sc := bufio.NewScanner(iotest.OneByteReader(strings.NewReader(tt.input)))
+			sc.Split(ScanUniversalLines)
+			var lines []string
+			for sc.Scan() {
+				lines = append(lines, sc.Text())
+			}
+			assert.NilError(t, sc.Err())
+			assert.DeepEqual(t, lines, tt.want)
+		})
+	}
+}
+
+func TestWithLineSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		diff   Diff
+		opts   []SearchOption
+		want   Edit
+	}{
+		{
+			name:   "carriage return",
+			source: "a\rb\rc\r",
+			diff:   Diff{Line: 2, Search: "b\n", Replace: "x\ny\n"},
+			want:   Edit{Start: 2, End: 4, Text: "x\ry\r"},
+		},
+		{
+			name:   "carriage return search text",
+			source: "a\rb\rc\r",
+			diff:   Diff{Search: "b\rc\r", Replace: "x\r"},
+			want:   Edit{Start: 2, End: 6, Text: "x\r"},
+		},
+		{
+			name:   "line separator",
+			source: "one\u2028two\u2028three\u2028",
+			diff:   Diff{Search: "three\n", Replace: "3\n"},
+			want:   Edit{Start: 12, End: 20, Text: "3\u2028"},
+		},
+		{
+			name:   "fuzzy",
+			source: "func f() {\r\treturn 1\r}\r",
+			diff:   Diff{Line: 2, Search: "\treturn 2\n", Replace: "\treturn 3\n"},
+			opts:   []SearchOption{WithThreshold(0.8)},
+			want:   Edit{Start: 11, End: 21, Text: "\treturn 3\r"},
+		},
+		{
+			name:   "byte range",
+			source: "x\u2028y\u2028x\u2028",
+			diff:   Diff{Search: "x\n", Replace: "z\n"},
+			opts:   []SearchOption{WithByteRange(4, 12)},
+			want:   Edit{Start: 8, End: 12, Text: "z\u2028"},
+		},
+		{
+			name:   "sub-line",
+			source: "a = 1\rb = 2\r",
+			diff:   Diff{Line: 2, Search: "2", Replace: "3"},
+			opts:   []SearchOption{WithSubLine()},
+			want:   Edit{Start: 10, End: 11, Text: "3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append(tt.opts, WithLineSplit(ScanUniversalLines))
+			edit, ok := Search(tt.source, tt.diff, opts...)
+			assert.Assert(t, ok)
+			assert.DeepEqual(t, edit, tt.want)
+
+			matches := SearchAll(tt.source, tt.diff, opts...)
+			assert.Assert(t, len(matches) > 0)
+			assert.DeepEqual(t, matches[0].Edit, tt.want)
+		})
+	}
+}
+
+func TestWithPatchLineSplit(t *testing.T) {
+	patch := "<<<<<<< SEARCH line:2\rb\r=======\rx\r>>>>>>> REPLACE\r"
+	want := []Diff{{Line: 2, Search: "b\r", Replace: "x\r", Span: Span{Start: 1, End: 5}}}
+
+	diffs, err := Parse(patch, WithPatchLineSplit(ScanUniversalLines))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, diffs, want)
+
+	var streamed []Diff
+	for diff, err := range ParseReader(strings.NewReader(patch), WithPatchLineSplit(ScanUniversalLines)) {
+		assert.NilError(t, err)
+		streamed = append(streamed, diff)
+	}
+	assert.DeepEqual(t, streamed, want)
+
+	source := "a\rb\rc\r"
+	edit, ok := Search(source, diffs[0], WithLineSplit(ScanUniversalLines))
+	assert.Assert(t, ok)
+	got, err := Apply(source, []Edit{edit})
+	assert.NilError(t, err)
+	assert.Equal(t, got, "a\rx\rc\r")
+}
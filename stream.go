@@ -0,0 +1,54 @@
+package fuzzypatch
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// ApplyStream is like Apply, but it copies the source from src to dst while
+// splicing in the edits, so the document doesn't have to fit in memory.
+// Overlapping edits are an error, and they're reported before anything is
+// written. An edit that ends past the end of src is only noticed once it's
+// reached, so dst holds the output up to it.
+func ApplyStream(dst io.Writer, src io.Reader, edits []Edit) error {
+	edits = slices.Clone(edits)
+	slices.SortStableFunc(edits, func(a, b Edit) int {
+		return cmp.Or(a.Start-b.Start, a.End-b.End)
+	})
+	last := 0
+	for _, e := range edits {
+		if e.Start < 0 || e.End < e.Start {
+			return fmt.Errorf("invalid edit range [%d,%d)", e.Start, e.End)
+		}
+		if e.Start < last {
+			return fmt.Errorf("%w at [%d,%d)", ErrOverlap, e.Start, e.End)
+		}
+		last = e.End
+	}
+	pos := 0 // offset in src
+	for _, e := range edits {
+		if _, err := io.CopyN(dst, src, int64(e.Start-pos)); err != nil {
+			return streamError(err, e)
+		}
+		if _, err := io.WriteString(dst, e.Text); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(io.Discard, src, int64(e.End-e.Start)); err != nil {
+			return streamError(err, e)
+		}
+		pos = e.End
+	}
+	_, err := io.Copy(dst, src)
+	return err
+}
+
+// streamError returns the error for a failure to read up to the end of e.
+func streamError(err error, e Edit) error {
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid edit range [%d,%d)", e.Start, e.End)
+	}
+	return err
+}
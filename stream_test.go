@@ -0,0 +1,62 @@
+package fuzzypatch
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestApplyStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		edits  []Edit
+		want   string
+		err    string
+	}{
+		{
+			name:   "no edits",
+			source: "hello\n",
+			want:   "hello\n",
+		},
+		{
+			name:   "edits",
+			source: "foo bar baz\n",
+			edits:  []Edit{{Start: 8, End: 11, Text: "qux"}, {Start: 0, End: 3, Text: "f"}, {Start: 4, End: 4, Text: "new "}},
+			want:   "f new bar qux\n",
+		},
+		{
+			name:   "append",
+			source: "a\n",
+			edits:  []Edit{{Start: 2, End: 2, Text: "b\n"}},
+			want:   "a\nb\n",
+		},
+		{
+			name:   "overlap",
+			source: "abcdef",
+			edits:  []Edit{{Start: 0, End: 3, Text: "x"}, {Start: 2, End: 4, Text: "y"}},
+			err:    "overlapping edits at [2,4)",
+		},
+		{
+			name:   "past the end",
+			source: "abc",
+			edits:  []Edit{{Start: 0, End: 1, Text: "A"}, {Start: 2, End: 9}},
+			want:   "Ab",
+			err:    "invalid edit range [2,9)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			err := ApplyStream(&b, iotest.OneByteReader(strings.NewReader(tt.source)), tt.edits)
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+			} else {
+				assert.NilError(t, err)
+			}
+			assert.Equal(t, b.String(), tt.want)
+		})
+	}
+}
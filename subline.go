@@ -0,0 +1,79 @@
+package fuzzypatch
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// WithSubLine matches search text that's a single line anywhere within a
+// line, instead of only against whole lines. The edit only replaces the
+// text that matched, and the final line endings of the search and replace
+// text are dropped. This makes minified and other single-line files
+// patchable.
+func WithSubLine() SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.subLine = true
+	}
+}
+
+// subLineMatch finds the best matching part of line i. When there isn't
+// one, the returned match still has its line and score.
+func (s *searcher) subLineMatch(i int) (Match, bool) {
+	line := strings.TrimSuffix(s.lines[i], s.eol)
+	search := s.cfg.normalize(s.diff.Search)
+	n := len(s.diff.Search)
+	from, to, best := 0, 0, -1.0
+	if s.cfg.literal() {
+		// only identical text scores 1, so look for it before scoring
+		for at, off := -1, 0; ; off = at + 1 {
+			k := strings.Index(line[off:], s.diff.Search)
+			if k < 0 {
+				break
+			}
+			if at = off + k; best < 1 || s.closerColumn(at, from) {
+				from, to, best = at, at+n, 1
+			}
+		}
+	}
+	for at := range line {
+		if best == 1 {
+			break
+		}
+		end := min(at+n, len(line))
+		for end < len(line) && !utf8.RuneStart(line[end]) {
+			end++
+		}
+		score := s.cfg.metric(s.cfg.normalize(line[at:end]), search)
+		if score > best || (score == best && s.closerColumn(at, from)) {
+			from, to, best = at, end, score
+		}
+		if end == len(line) {
+			break // later windows are only shorter
+		}
+	}
+	failed := Match{Score: best, Line: i + 1, LineEnd: i + 1, Distance: s.distance(i)}
+	if best < s.threshold {
+		return failed, false
+	}
+	start, end := s.offsets[i]+from, s.offsets[i]+to
+	if s.excluded(start, end) || !s.inRegion(i, i+1) || s.rejected(i, i+1) {
+		return failed, false
+	}
+	return s.expand(Match{
+		Edit: Edit{
+			Start: s.shift + start,
+			End:   s.shift + end,
+			Text:  s.diff.Replace,
+		},
+		Score:    best,
+		Line:     i + 1,
+		LineEnd:  i + 1,
+		Distance: s.distance(i),
+	}), true
+}
+
+// closerColumn reports whether byte offset a within a line is closer to the
+// column hint than b.
+func (s *searcher) closerColumn(a, b int) bool {
+	return s.diff.Column > 0 && abs(a+1-s.diff.Column) < abs(b+1-s.diff.Column)
+}
@@ -0,0 +1,90 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSubLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		diff   Diff
+		opts   []SearchOption
+		found  bool
+		want   Edit
+	}{
+		{
+			name:   "exact",
+			source: "var a=1;var b=2;var c=3;\n",
+			diff:   Diff{Line: 1, Search: "var b=2;\n", Replace: "var b=3;\n"},
+			opts:   []SearchOption{WithSubLine()},
+			found:  true,
+			want:   Edit{Start: 8, End: 16, Text: "var b=3;"},
+		},
+		{
+			name:   "fuzzy",
+			source: "var a=1;var b=2;var c=3;\n",
+			diff:   Diff{Line: 1, Search: "var b=5;\n", Replace: "var b=3;\n"},
+			opts:   []SearchOption{WithThreshold(0.8), WithSubLine()},
+			found:  true,
+			want:   Edit{Start: 8, End: 16, Text: "var b=3;"},
+		},
+		{
+			name:   "nearest line",
+			source: "a=1\nb=2;a=3;\nc=4;a=5;\n",
+			diff:   Diff{Line: 3, Search: "a=5;\n", Replace: "a=6;\n"},
+			opts:   []SearchOption{WithSubLine()},
+			found:  true,
+			want:   Edit{Start: 17, End: 21, Text: "a=6;"},
+		},
+		{
+			name:   "column hint",
+			source: "x=1;x=1;x=1;\n",
+			diff:   Diff{Line: 1, Column: 5, Search: "x=1;\n", Replace: "y=1;\n"},
+			opts:   []SearchOption{WithSubLine()},
+			found:  true,
+			want:   Edit{Start: 4, End: 8, Text: "y=1;"},
+		},
+		{
+			name:   "fuzzy column hint",
+			source: "x=1;x=1;x=1;\n",
+			diff:   Diff{Line: 1, Column: 9, Search: "x=2;\n", Replace: "y=1;\n"},
+			opts:   []SearchOption{WithThreshold(0.7), WithSubLine()},
+			found:  true,
+			want:   Edit{Start: 8, End: 12, Text: "y=1;"},
+		},
+		{
+			name:   "multi-byte runes",
+			source: "«a» «b» «c»\n",
+			diff:   Diff{Line: 1, Column: 7, Search: "«x»\n", Replace: "«B»\n"},
+			opts:   []SearchOption{WithThreshold(0.6), WithSubLine()},
+			found:  true,
+			want:   Edit{Start: 6, End: 11, Text: "«B»"},
+		},
+		{
+			name:   "multi-line search",
+			source: "a\nb\nc\n",
+			diff:   Diff{Line: 1, Search: "b\nc\n", Replace: "x\n"},
+			opts:   []SearchOption{WithSubLine()},
+			found:  true,
+			want:   Edit{Start: 2, End: 6, Text: "x\n"},
+		},
+		{
+			name:   "disabled",
+			source: "var a=1;var b=2;var c=3;\n",
+			diff:   Diff{Line: 1, Search: "var b=2;\n", Replace: "var b=3;\n"},
+			found:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edit, ok := Search(tt.source, tt.diff, tt.opts...)
+			assert.Equal(t, ok, tt.found)
+			if tt.found {
+				assert.DeepEqual(t, edit, tt.want)
+			}
+		})
+	}
+}
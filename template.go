@@ -0,0 +1,38 @@
+package fuzzypatch
+
+import (
+	"strconv"
+	"strings"
+)
+
+// WithTemplates expands placeholders in the replace text using the region
+// that was matched, so a patch can adapt to the document it's applied to:
+//
+//   - {{matched}} is the matched text, without its final line ending
+//   - {{indent}} is the leading spaces and tabs of the first matched line
+//   - {{line}} is the 1-based line number where the match starts
+func WithTemplates() SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.templates = true
+	}
+}
+
+// expand returns m with the placeholders in its text expanded.
+func (s *searcher) expand(m Match) Match {
+	if !s.cfg.templates {
+		return m
+	}
+	matched := s.source[m.Edit.Start-s.shift : m.Edit.End-s.shift]
+	matched = strings.TrimSuffix(matched, s.eol)
+	var indent string
+	if i := m.Line - 1; i < len(s.lines) {
+		line := s.lines[i]
+		indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	}
+	m.Edit.Text = strings.NewReplacer(
+		"{{matched}}", matched,
+		"{{indent}}", indent,
+		"{{line}}", strconv.Itoa(m.Line),
+	).Replace(m.Edit.Text)
+	return m
+}
@@ -0,0 +1,66 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestTemplates(t *testing.T) {
+	source := "func f() {\n\t\treturn 1\n}\n\nfunc g() {}\n"
+	tests := []struct {
+		name string
+		diff Diff
+		opts []SearchOption
+		want Edit
+	}{
+		{
+			name: "matched",
+			diff: Diff{Line: 2, Search: "\t\treturn 1\n", Replace: "\t\t// TODO\n{{matched}}\n"},
+			opts: []SearchOption{WithTemplates()},
+			want: Edit{Start: 11, End: 22, Text: "\t\t// TODO\n\t\treturn 1\n"},
+		},
+		{
+			name: "indent",
+			diff: Diff{Line: 2, Search: "return 1\n", Replace: "{{indent}}return 2\n"},
+			opts: []SearchOption{WithTemplates(), WithWhitespace(WhitespaceTrim)},
+			want: Edit{Start: 11, End: 22, Text: "\t\treturn 2\n"},
+		},
+		{
+			name: "indent of a blank line",
+			diff: Diff{Line: 4, Search: "\nfunc g() {}\n", Replace: "\n{{indent}}func g() {\n}\n"},
+			opts: []SearchOption{WithTemplates()},
+			want: Edit{Start: 24, End: 37, Text: "\nfunc g() {\n}\n"},
+		},
+		{
+			name: "line",
+			diff: Diff{Line: 1, Search: "\t\treturn 1\n", Replace: "\t\treturn {{line}}\n"},
+			opts: []SearchOption{WithTemplates()},
+			want: Edit{Start: 11, End: 22, Text: "\t\treturn 2\n"},
+		},
+		{
+			name: "insertion",
+			diff: Diff{Line: 2, Replace: "{{indent}}x := {{line}}{{matched}}\n"},
+			opts: []SearchOption{WithTemplates()},
+			want: Edit{Start: 11, End: 11, Text: "\t\tx := 2\n"},
+		},
+		{
+			name: "regex",
+			diff: Diff{Line: 2, Regex: true, Search: `\d+`, Replace: "{{matched}}0"},
+			opts: []SearchOption{WithTemplates()},
+			want: Edit{Start: 20, End: 21, Text: "10"},
+		},
+		{
+			name: "disabled",
+			diff: Diff{Line: 2, Search: "\t\treturn 1\n", Replace: "{{matched}}\n"},
+			want: Edit{Start: 11, End: 22, Text: "{{matched}}\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edit, ok := Search(source, tt.diff, tt.opts...)
+			assert.Assert(t, ok)
+			assert.DeepEqual(t, edit, tt.want)
+		})
+	}
+}
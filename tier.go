@@ -0,0 +1,65 @@
+package fuzzypatch
+
+import "errors"
+
+// Tier is the stage of SearchTiered that found a match. Lower tiers are
+// stricter.
+type Tier int
+
+const (
+	TierExact      Tier = iota + 1 // The text matched exactly
+	TierWhitespace                 // The text matched once whitespace was collapsed
+	TierFuzzy                      // The text matched with the caller's options
+)
+
+func (t Tier) String() string {
+	switch t {
+	case TierExact:
+		return "exact"
+	case TierWhitespace:
+		return "whitespace"
+	case TierFuzzy:
+		return "fuzzy"
+	default:
+		return "unknown"
+	}
+}
+
+// SearchTiered is like SearchMatch, but it tries an exact match first, then
+// a match where only the whitespace differs, and only then a match with the
+// given options, and reports which tier found it. The first two tiers use
+// the options too, but they require a score of 1 and turn off the options
+// that make different text compare equal, such as WithCaseFolding,
+// WithoutComments, WithMetric, WithEllipsis, WithThresholdPolicy, and
+// diff.Threshold. Insertions and regex diffs are always exact.
+func SearchTiered(source string, diff Diff, opts ...SearchOption) (Match, Tier, error) {
+	if diff.Search == "" || diff.Regex {
+		m, err := SearchMatch(source, diff, opts...)
+		return m, TierExact, err
+	}
+	strict := diff
+	strict.Threshold = 0
+	for tier, mode := range []WhitespaceMode{WhitespaceExact, WhitespaceCollapse} {
+		opts := append(opts[:len(opts):len(opts)], exactly(mode))
+		m, err := SearchMatch(source, strict, opts...)
+		if errors.Is(err, ErrNoMatch) || err == nil && m.Score < 1 {
+			continue
+		}
+		return m, TierExact + Tier(tier), err
+	}
+	m, err := SearchMatch(source, diff, opts...)
+	return m, TierFuzzy, err
+}
+
+// exactly requires a score of 1 and turns off the options that normalize the
+// text before it's compared, except for the whitespace mode.
+func exactly(mode WhitespaceMode) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.threshold, cfg.policy = 1, nil
+		cfg.whitespace = mode
+		cfg.foldCase, cfg.nfc, cfg.ascii, cfg.indent = false, false, false, false
+		cfg.tabWidth, cfg.anchors, cfg.heights = 0, 0, 0
+		cfg.comments = CommentSyntax{}
+		cfg.metric, cfg.ellipsis = Levenshtein, false
+	}
+}
@@ -0,0 +1,103 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSearchTiered(t *testing.T) {
+	source := "foo  bar\nbaz\nfoo bar\n"
+	tests := []struct {
+		name string
+		diff Diff
+		opts []SearchOption
+		line int
+		tier Tier
+		err  error
+	}{
+		{
+			name: "exact",
+			diff: Diff{Line: 1, Search: "foo bar\n", Replace: "x\n"},
+			line: 3,
+			tier: TierExact,
+		},
+		{
+			name: "exact ignores the diff threshold",
+			diff: Diff{Line: 1, Threshold: 0.5, Search: "foo bar\n", Replace: "x\n"},
+			line: 3,
+			tier: TierExact,
+		},
+		{
+			name: "exact ignores the threshold policy",
+			diff: Diff{Line: 3, Search: "foo bax\n", Replace: "x\n"},
+			opts: []SearchOption{WithThresholdPolicy(LengthAdaptive(0.5, 1))},
+			line: 3,
+			tier: TierFuzzy,
+		},
+		{
+			name: "case folding is fuzzy",
+			diff: Diff{Line: 1, Search: "FOO BAR\n", Replace: "x\n"},
+			opts: []SearchOption{WithCaseFolding()},
+			line: 3,
+			tier: TierFuzzy,
+		},
+		{
+			name: "exact ignores the metric",
+			diff: Diff{Line: 1, Search: "foo bar\n", Replace: "x\n"},
+			opts: []SearchOption{WithMetric(TokenSimilarity)},
+			line: 3,
+			tier: TierExact,
+		},
+		{
+			name: "ellipsis is fuzzy",
+			diff: Diff{Line: 1, Search: "foo  bar\n...\nfoo bar\n", Replace: "x\n"},
+			opts: []SearchOption{WithEllipsis()},
+			line: 1,
+			tier: TierFuzzy,
+		},
+		{
+			name: "whitespace",
+			diff: Diff{Line: 1, Search: "baz \n", Replace: "x\n"},
+			line: 2,
+			tier: TierWhitespace,
+		},
+		{
+			name: "fuzzy",
+			diff: Diff{Line: 1, Search: "baa\n", Replace: "x\n"},
+			opts: []SearchOption{WithThreshold(0.5)},
+			line: 2,
+			tier: TierFuzzy,
+		},
+		{
+			name: "insertion",
+			diff: Diff{Line: 2, Replace: "x\n"},
+			line: 2,
+			tier: TierExact,
+		},
+		{
+			name: "no match",
+			diff: Diff{Line: 1, Search: "qux\n", Replace: "x\n"},
+			err:  ErrNoMatch,
+		},
+		{
+			name: "ambiguous exact match",
+			diff: Diff{Line: 2, Search: "foo\n", Replace: "x\n"},
+			opts: []SearchOption{WithSubLine(), WithAmbiguityCheck(0)},
+			err:  ErrAmbiguousMatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, tier, err := SearchTiered(source, tt.diff, tt.opts...)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, m.Line, tt.line)
+			assert.Equal(t, tier, tt.tier)
+			assert.Equal(t, tier.String(), [...]string{"", "exact", "whitespace", "fuzzy"}[tt.tier])
+		})
+	}
+}
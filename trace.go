@@ -0,0 +1,93 @@
+package fuzzypatch
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Trace records the candidates that were scored while searching, to explain
+// why a diff matched where it did, or why it didn't match at all.
+// It isn't safe to share a Trace between concurrent searches.
+type Trace struct {
+	Candidates []Candidate
+}
+
+// Candidate is a region of the document that was compared with the search
+// text.
+type Candidate struct {
+	Line    int     // 1-based first line
+	LineEnd int     // 1-based last line (inclusive)
+	Score   float64 // similarity to the search text
+	Passed  bool    // whether it was accepted as a match
+}
+
+func (c Candidate) String() string {
+	lines := fmt.Sprintf("line %d", c.Line)
+	if c.LineEnd > c.Line {
+		lines = fmt.Sprintf("lines %d-%d", c.Line, c.LineEnd)
+	}
+	return fmt.Sprintf("%.2f at %s", c.Score, lines)
+}
+
+// WithTrace appends every candidate that's scored to t.Candidates, in the
+// order they're evaluated. The candidates that WithAmbiguityCheck scores
+// after a match is found aren't included.
+func WithTrace(t *Trace) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.trace = t
+	}
+}
+
+// Closest returns the candidate with the highest score, preferring the one
+// evaluated first, or false if there weren't any.
+func (t *Trace) Closest() (Candidate, bool) {
+	var best Candidate
+	found := false
+	for _, c := range t.Candidates {
+		if !found || c.Score > best.Score {
+			best, found = c, true
+		}
+	}
+	return best, found
+}
+
+// WithClosest makes SearchMatch return a NoMatchError listing the k best
+// scoring candidates instead of a bare ErrNoMatch, so failures can be
+// debugged without a Trace.
+func WithClosest(k int) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.closest = k
+	}
+}
+
+// record adds m to the trace, and keeps it if it's one of the closest
+// candidates.
+func (s *searcher) record(m Match, passed bool) {
+	c := Candidate{
+		Line:    m.Line,
+		LineEnd: m.LineEnd,
+		Score:   m.Score,
+		Passed:  passed,
+	}
+	if s.cfg.trace != nil {
+		s.cfg.trace.Candidates = append(s.cfg.trace.Candidates, c)
+	}
+	if k := s.cfg.closest; k > 0 && c.Score >= 0 {
+		// ties keep the candidate that was evaluated first
+		i := len(s.closest)
+		for i > 0 && s.closest[i-1].Score < c.Score {
+			i--
+		}
+		if i < k {
+			s.closest = slices.Insert(s.closest, i, c)[:min(k, len(s.closest)+1)]
+		}
+	}
+}
+
+// noMatch returns the error for a diff that didn't match.
+func (s *searcher) noMatch() error {
+	if s.cfg.closest <= 0 {
+		return ErrNoMatch
+	}
+	return &NoMatchError{Candidates: s.closest}
+}
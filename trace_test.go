@@ -0,0 +1,52 @@
+package fuzzypatch
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestTrace(t *testing.T) {
+	source := "foo\nbar\nfoa\n"
+
+	var trace Trace
+	m, err := SearchMatch(source, Diff{Line: 2, Search: "foo\n", Replace: "x\n"}, WithThreshold(0.7), WithTrace(&trace))
+	assert.NilError(t, err)
+	assert.Equal(t, m.Line, 1)
+	assert.DeepEqual(t, trace.Candidates, []Candidate{
+		{Line: 2, LineEnd: 2, Score: 0.25},
+		{Line: 1, LineEnd: 1, Score: 1, Passed: true},
+	})
+
+	// the ambiguity check doesn't record the candidates again
+	trace = Trace{}
+	_, err = SearchMatch(source, Diff{Line: 2, Search: "foo\n", Replace: "x\n"}, WithThreshold(0.7), WithAmbiguityCheck(0), WithTrace(&trace))
+	assert.NilError(t, err)
+	assert.Equal(t, len(trace.Candidates), 2)
+
+	trace = Trace{}
+	_, err = SearchMatch(source, Diff{Line: 2, Search: "fob\nbaz\n", Replace: "x\n"}, WithTrace(&trace))
+	assert.ErrorIs(t, err, ErrNoMatch)
+	assert.Equal(t, len(trace.Candidates), 2)
+	closest, ok := trace.Closest()
+	assert.Assert(t, ok)
+	assert.Equal(t, closest.String(), "0.75 at lines 1-2")
+
+	_, ok = (&Trace{}).Closest()
+	assert.Assert(t, !ok)
+}
+
+func TestWithClosest(t *testing.T) {
+	source := "alpha\nbeta\ngamma\n"
+
+	_, err := SearchMatch(source, Diff{Line: 2, Search: "betta\n", Replace: "x\n"}, WithThreshold(0.95), WithClosest(2))
+	var nomatch *NoMatchError
+	assert.Assert(t, errors.As(err, &nomatch))
+	assert.ErrorIs(t, err, ErrNoMatch)
+	assert.Equal(t, len(nomatch.Candidates), 2)
+	assert.Error(t, err, "no match: closest 0.83 at line 2, 0.33 at line 1")
+
+	_, err = SearchMatch(source, Diff{Search: "betta\n", Replace: "x\n"}, WithThreshold(0.95))
+	assert.Equal(t, err, ErrNoMatch)
+}
@@ -0,0 +1,273 @@
+package fuzzypatch
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParseUnified parses unified diff text into a list of diffs.
+// Each hunk becomes a Diff whose Search is the context and removed lines,
+// whose Replace is the context and added lines, and whose Line is the
+// hunk's starting line in the original file.
+// File headers are accepted but ignored.
+func ParseUnified(input string) ([]Diff, error) {
+	lines := slices.Collect(strings.Lines(input))
+	var diffs []Diff
+	for i := 0; i < len(lines); {
+		if !strings.HasPrefix(lines[i], "@@") {
+			i++
+			continue
+		}
+		diff, n, err := parseHunk(lines[i:], i)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, diff)
+		i += n
+	}
+	return diffs, nil
+}
+
+// FromUnified converts unified diff text into SEARCH/REPLACE diffs which
+// can be applied fuzzily with Search. It's equivalent to ParseUnified.
+func FromUnified(diffText string) ([]Diff, error) {
+	return ParseUnified(diffText)
+}
+
+// parseHunk parses a single hunk starting at lines[0], which must be the
+// "@@" header. It returns the diff and the number of lines consumed.
+// The offset is only used for error messages.
+func parseHunk(lines []string, offset int) (Diff, int, error) {
+	header := strings.TrimRight(lines[0], "\r\n")
+	m := hunkHeaderRe.FindStringSubmatch(header)
+	if m == nil {
+		return Diff{}, 0, &ParseError{Line: offset + 1, Text: header, Msg: "invalid hunk header"}
+	}
+	oldStart, _ := strconv.Atoi(m[1])
+	oldCount := 1
+	if m[2] != "" {
+		oldCount, _ = strconv.Atoi(m[2])
+	}
+	newCount := 1
+	if m[4] != "" {
+		newCount, _ = strconv.Atoi(m[4])
+	}
+
+	var diff Diff
+	diff.Line = oldStart
+	diff.Span.Start = offset + 1
+	if oldCount == 0 {
+		// a pure insertion happens after oldStart
+		diff.Line = oldStart + 1
+	}
+
+	// last tracks which side(s) the previous line was added to,
+	// so that "\ No newline at end of file" can be applied to it.
+	const (
+		none = iota
+		oldSide
+		newSide
+		bothSides
+	)
+	last := none
+	n := 1
+	for ; n < len(lines) && (oldCount > 0 || newCount > 0 || strings.HasPrefix(lines[n], `\`)); n++ {
+		line := lines[n]
+		switch {
+		case strings.HasPrefix(line, `\`):
+			if last == oldSide || last == bothSides {
+				diff.Search = strings.TrimSuffix(diff.Search, "\n")
+			}
+			if last == newSide || last == bothSides {
+				diff.Replace = strings.TrimSuffix(diff.Replace, "\n")
+			}
+			last = none
+		case strings.HasPrefix(line, "-"):
+			diff.Search += line[1:]
+			oldCount--
+			last = oldSide
+		case strings.HasPrefix(line, "+"):
+			diff.Replace += line[1:]
+			newCount--
+			last = newSide
+		case strings.HasPrefix(line, " "):
+			diff.Search += line[1:]
+			diff.Replace += line[1:]
+			oldCount--
+			newCount--
+			last = bothSides
+		case strings.TrimRight(line, "\r\n") == "":
+			// some tools strip the leading space from blank context lines
+			diff.Search += line
+			diff.Replace += line
+			oldCount--
+			newCount--
+			last = bothSides
+		default:
+			return Diff{}, 0, &ParseError{Line: offset + n + 1, Text: line, Msg: "unexpected line in hunk"}
+		}
+	}
+	if oldCount > 0 || newCount > 0 {
+		return Diff{}, 0, &ParseError{Line: offset + 1, Text: header, Msg: "unexpected end of hunk"}
+	}
+	if oldCount < 0 || newCount < 0 {
+		return Diff{}, 0, &ParseError{Line: offset + 1, Text: header, Msg: "hunk line counts do not match header"}
+	}
+	diff.Span.End = offset + n
+	return diff, n, nil
+}
+
+// unifiedContext is the number of context lines written around each change.
+const unifiedContext = 3
+
+// lineChange replaces lines [start, end) of a document with text.
+type lineChange struct {
+	start, end int
+	text       string
+}
+
+// ToUnified returns a unified diff of the changes that applying edits to
+// source would make. Edits are widened to whole lines, and changes that are
+// close together share a hunk. Only the hunks are written, so callers that
+// need file headers must add them. It returns an empty string if the edits
+// don't change anything.
+func ToUnified(source string, edits []Edit) string {
+	lines := trimSplit(source)
+	offsets := make([]int, len(lines)+1)
+	for i, l := range lines {
+		offsets[i+1] = offsets[i] + len(l)
+	}
+	changes := lineChanges(source, offsets, edits)
+	var b strings.Builder
+	delta := 0 // difference in line count between the new and old documents
+	for i := 0; i < len(changes); {
+		// group the changes whose context overlaps
+		j := i + 1
+		for j < len(changes) && changes[j].start-changes[j-1].end <= 2*unifiedContext {
+			j++
+		}
+		start := max(0, changes[i].start-unifiedContext)
+		end := min(len(lines), changes[j-1].end+unifiedContext)
+		var body strings.Builder
+		oldCount, newCount := 0, 0
+		context := func(from, to int) {
+			for _, line := range lines[from:to] {
+				writeUnifiedLine(&body, " ", line)
+			}
+			oldCount += to - from
+			newCount += to - from
+		}
+		pos := start
+		for _, c := range changes[i:j] {
+			context(pos, c.start)
+			for _, line := range lines[c.start:c.end] {
+				writeUnifiedLine(&body, "-", line)
+			}
+			added := trimSplit(c.text)
+			for _, line := range added {
+				writeUnifiedLine(&body, "+", line)
+			}
+			oldCount += c.end - c.start
+			newCount += len(added)
+			pos = c.end
+		}
+		context(pos, end)
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n",
+			hunkRange(start, oldCount),
+			hunkRange(start+delta, newCount),
+		)
+		b.WriteString(body.String())
+		delta += newCount - oldCount
+		i = j
+	}
+	return b.String()
+}
+
+// Preview is like ToUnified, but it first checks that the edits can be
+// applied, so a dry run fails the same way Apply would. Neither source nor
+// edits are modified.
+func Preview(source string, edits []Edit) (string, error) {
+	if _, _, err := ApplyReport(source, edits); err != nil {
+		return "", err
+	}
+	return ToUnified(source, edits), nil
+}
+
+// lineChanges widens the edits to whole lines, merging edits that touch
+// the same lines. Changes that leave their lines unchanged are dropped.
+func lineChanges(source string, offsets []int, edits []Edit) []lineChange {
+	edits = slices.Clone(edits)
+	slices.SortStableFunc(edits, func(a, b Edit) int { return a.Start - b.Start })
+	nLines := len(offsets) - 1
+	lineOf := func(offset int) int {
+		i, _ := slices.BinarySearch(offsets, offset+1)
+		return max(0, i-1)
+	}
+	// lineRange returns the lines [start, end) covered by an edit
+	lineRange := func(e Edit) (int, int) {
+		start := lineOf(e.Start)
+		if start == nLines && start > 0 && !strings.HasSuffix(source, "\n") {
+			// appending to an unterminated line changes that line
+			start--
+		}
+		if e.End == offsets[start] {
+			return start, start
+		}
+		return start, lineOf(e.End-1) + 1
+	}
+	var changes []lineChange
+	for i := 0; i < len(edits); {
+		start, end := lineRange(edits[i])
+		j := i + 1
+		for ; j < len(edits); j++ {
+			s, e := lineRange(edits[j])
+			if s >= end && !(s == start && e == end) {
+				break
+			}
+			end = max(end, e)
+		}
+		region := source[offsets[start]:offsets[end]]
+		shifted := make([]Edit, 0, j-i)
+		for _, e := range edits[i:j] {
+			shifted = append(shifted, Edit{
+				Start: e.Start - offsets[start],
+				End:   e.End - offsets[start],
+				Text:  e.Text,
+			})
+		}
+		text, err := Apply(region, shifted)
+		if err == nil && text != region {
+			changes = append(changes, lineChange{start: start, end: end, text: text})
+		}
+		i = j
+	}
+	return changes
+}
+
+// hunkRange formats the start and count of one side of a hunk header.
+// The start is 0-based, and it's written as the line before the hunk when
+// the count is 0.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return strconv.Itoa(start + 1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
+
+// writeUnifiedLine writes a hunk line, marking lines without a newline.
+func writeUnifiedLine(b *strings.Builder, prefix, line string) {
+	b.WriteString(prefix)
+	b.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		b.WriteString("\n\\ No newline at end of file\n")
+	}
+}
@@ -0,0 +1,200 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseUnified(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		diffs []Diff
+		err   bool
+	}{
+		{
+			name:  "empty input",
+			input: "",
+			diffs: nil,
+		},
+		{
+			name: "single hunk with headers",
+			input: "--- a/file.txt\n+++ b/file.txt\n" +
+				"@@ -2,3 +2,3 @@\n foo\n-bar\n+baz\n qux\n",
+			diffs: []Diff{{
+				Line:    2,
+				Search:  "foo\nbar\nqux\n",
+				Replace: "foo\nbaz\nqux\n",
+			}},
+		},
+		{
+			name: "multiple hunks",
+			input: "@@ -1 +1 @@\n-a\n+b\n" +
+				"@@ -10,2 +10,3 @@\n c\n+d\n e\n",
+			diffs: []Diff{
+				{Line: 1, Search: "a\n", Replace: "b\n"},
+				{Line: 10, Search: "c\ne\n", Replace: "c\nd\ne\n"},
+			},
+		},
+		{
+			name:  "pure insertion",
+			input: "@@ -3,0 +4,1 @@\n+new\n",
+			diffs: []Diff{{Line: 4, Search: "", Replace: "new\n"}},
+		},
+		{
+			name:  "no newline at end of file",
+			input: "@@ -1 +1 @@\n-old\n\\ No newline at end of file\n+new\n\\ No newline at end of file\n",
+			diffs: []Diff{{Line: 1, Search: "old", Replace: "new"}},
+		},
+		{
+			name:  "blank context line without leading space",
+			input: "@@ -1,3 +1,3 @@\n a\n\n-b\n+c\n",
+			diffs: []Diff{{Line: 1, Search: "a\n\nb\n", Replace: "a\n\nc\n"}},
+		},
+		{
+			name:  "truncated hunk",
+			input: "@@ -1,3 +1,3 @@\n a\n",
+			err:   true,
+		},
+		{
+			name:  "invalid hunk header",
+			input: "@@ -x +1 @@\n a\n",
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := ParseUnified(tt.input)
+			if tt.err {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, diffs, tt.diffs, ignoreSpans)
+		})
+	}
+}
+
+func TestParseUnifiedSpans(t *testing.T) {
+	input := "--- a/file.txt\n+++ b/file.txt\n" +
+		"@@ -1 +1 @@\n-a\n+b\n" +
+		"@@ -10,2 +10,2 @@\n c\n-d\n+e\n"
+	diffs, err := ParseUnified(input)
+	assert.NilError(t, err)
+	assert.Equal(t, len(diffs), 2)
+	assert.DeepEqual(t, diffs[0].Span, Span{Start: 3, End: 5})
+	assert.DeepEqual(t, diffs[1].Span, Span{Start: 6, End: 9})
+}
+
+func TestFromUnified(t *testing.T) {
+	diffs, err := FromUnified("@@ -2,3 +2,3 @@\n foo\n-bar\n+baz\n qux\n")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, diffs, []Diff{{
+		Line:    2,
+		Search:  "foo\nbar\nqux\n",
+		Replace: "foo\nbaz\nqux\n",
+		Span:    Span{Start: 1, End: 5},
+	}})
+}
+
+func TestToUnified(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		edits  []Edit
+		want   string
+	}{
+		{
+			name:   "no edits",
+			source: "a\nb\n",
+			want:   "",
+		},
+		{
+			name:   "no-op edit",
+			source: "a\nb\n",
+			edits:  []Edit{{Start: 0, End: 2, Text: "a\n"}},
+			want:   "",
+		},
+		{
+			name:   "replace line",
+			source: "a\nb\nc\n",
+			edits:  []Edit{{Start: 2, End: 4, Text: "B\n"}},
+			want:   "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:   "partial line edit",
+			source: "hello world\n",
+			edits:  []Edit{{Start: 6, End: 11, Text: "there"}},
+			want:   "@@ -1 +1 @@\n-hello world\n+hello there\n",
+		},
+		{
+			name:   "insertion",
+			source: "a\nb\n",
+			edits:  []Edit{{Start: 2, End: 2, Text: "x\n"}},
+			want:   "@@ -1,2 +1,3 @@\n a\n+x\n b\n",
+		},
+		{
+			name:   "insertion into empty source",
+			source: "",
+			edits:  []Edit{{Start: 0, End: 0, Text: "x\n"}},
+			want:   "@@ -0,0 +1 @@\n+x\n",
+		},
+		{
+			name:   "deletion",
+			source: "a\nb\nc\n",
+			edits:  []Edit{{Start: 0, End: 6}},
+			want:   "@@ -1,3 +0,0 @@\n-a\n-b\n-c\n",
+		},
+		{
+			name:   "append to unterminated line",
+			source: "a\nb",
+			edits:  []Edit{{Start: 3, End: 3, Text: "\nc\n"}},
+			want:   "@@ -1,2 +1,3 @@\n a\n-b\n\\ No newline at end of file\n+b\n+c\n",
+		},
+		{
+			name:   "edits on the same line",
+			source: "abc\n",
+			edits:  []Edit{{Start: 2, End: 3, Text: "C"}, {Start: 0, End: 1, Text: "A"}},
+			want:   "@@ -1 +1 @@\n-abc\n+AbC\n",
+		},
+		{
+			name:   "separate hunks",
+			source: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			edits: []Edit{
+				{Start: 0, End: 2, Text: "one\n"},
+				{Start: 18, End: 21, Text: ""},
+			},
+			want: "@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+				"@@ -7,4 +7,3 @@\n 7\n 8\n 9\n-10\n",
+		},
+		{
+			name:   "nearby changes share a hunk",
+			source: "1\n2\n3\n4\n5\n",
+			edits: []Edit{
+				{Start: 0, End: 2, Text: "one\n"},
+				{Start: 8, End: 10, Text: "five\n"},
+			},
+			want: "@@ -1,5 +1,5 @@\n-1\n+one\n 2\n 3\n 4\n-5\n+five\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, ToUnified(tt.source, tt.edits), tt.want)
+		})
+	}
+}
+
+func TestPreview(t *testing.T) {
+	source := "a\nb\nc\n"
+	edits := []Edit{{Start: 4, End: 6, Text: "C\n"}, {Start: 0, End: 2, Text: "A\n"}}
+	got, err := Preview(source, edits)
+	assert.NilError(t, err)
+	assert.Equal(t, got, "@@ -1,3 +1,3 @@\n-a\n+A\n b\n-c\n+C\n")
+	assert.DeepEqual(t, edits, []Edit{{Start: 4, End: 6, Text: "C\n"}, {Start: 0, End: 2, Text: "A\n"}})
+
+	_, err = Preview(source, []Edit{{Start: 0, End: 4, Text: "x"}, {Start: 2, End: 6, Text: "y"}})
+	assert.ErrorContains(t, err, "overlapping edits")
+}
@@ -0,0 +1,114 @@
+package fuzzypatch
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	v4aBegin      = "*** Begin Patch"
+	v4aEnd        = "*** End Patch"
+	v4aUpdate     = "*** Update File: "
+	v4aAdd        = "*** Add File: "
+	v4aDelete     = "*** Delete File: "
+	v4aMove       = "*** Move to: "
+	v4aEndOfFile  = "*** End of File"
+	v4aHunkPrefix = "@@"
+)
+
+// ParseV4A parses the patch format used by OpenAI's apply_patch tool:
+//
+//	*** Begin Patch
+//	*** Update File: path/to/file
+//	@@ optional anchor
+//	 context
+//	-removed
+//	+added
+//	*** End Patch
+//
+// The format has no line numbers, so each chunk's line hint is synthesized
+// from the number of original lines in the chunks before it. Since chunks
+// appear in file order, the hint is a lower bound on the chunk's location.
+// A chunk followed by "*** End of File" has the EndOfFile hint instead. The
+// text after "@@" isn't used, since the chunk's context locates it already.
+// Added files are a single insertion of their contents, and deleted files
+// have no diffs and an empty Path.
+func ParseV4A(input string) (Patch, error) {
+	var patch Patch
+	var (
+		begun bool
+		file  *FileDiff
+		diff  *Diff
+		line  int // synthesized 1-based line of the current chunk
+	)
+	flush := func() {
+		if diff != nil && (diff.Search != "" || diff.Replace != "") {
+			file.Diffs = append(file.Diffs, *diff)
+			line += strings.Count(diff.Search, "\n")
+		}
+		diff = nil
+	}
+	addFile := func(f FileDiff) {
+		flush()
+		patch.Files = append(patch.Files, f)
+		file = &patch.Files[len(patch.Files)-1]
+		line = 1
+	}
+	lineNo := 0
+	for text := range strings.Lines(input) {
+		trim := strings.TrimRight(text, "\r\n")
+		lineNo++
+		if !begun {
+			if trim == v4aBegin {
+				begun = true
+			} else if strings.TrimSpace(trim) != "" {
+				return Patch{}, &ParseError{Line: lineNo, Text: trim, Msg: "expected " + strconv.Quote(v4aBegin)}
+			}
+			continue
+		}
+		switch {
+		case trim == v4aEnd:
+			flush()
+			return patch, nil
+		case strings.HasPrefix(trim, v4aUpdate):
+			path := strings.TrimSpace(strings.TrimPrefix(trim, v4aUpdate))
+			addFile(FileDiff{Path: path, OldPath: path})
+		case strings.HasPrefix(trim, v4aAdd):
+			addFile(FileDiff{Path: strings.TrimSpace(strings.TrimPrefix(trim, v4aAdd)), Op: FileCreate})
+			diff = &Diff{Line: 1}
+		case strings.HasPrefix(trim, v4aDelete):
+			addFile(FileDiff{OldPath: strings.TrimSpace(strings.TrimPrefix(trim, v4aDelete)), Op: FileDelete})
+		case strings.HasPrefix(trim, v4aMove) && file != nil:
+			file.Path = strings.TrimSpace(strings.TrimPrefix(trim, v4aMove))
+			file.Op = FileRename
+		case trim == v4aEndOfFile:
+			// the chunk is anchored to the end of the file
+			if diff != nil {
+				diff.Line = EndOfFile
+			}
+		case file == nil:
+			return Patch{}, &ParseError{Line: lineNo, Text: trim, Msg: "expected file header"}
+		case strings.HasPrefix(trim, v4aHunkPrefix):
+			flush()
+		default:
+			if diff == nil {
+				diff = &Diff{Line: line}
+			}
+			switch {
+			case strings.HasPrefix(text, "+"):
+				diff.Replace += text[1:]
+			case strings.HasPrefix(text, "-"):
+				diff.Search += text[1:]
+			case strings.HasPrefix(text, " "):
+				diff.Search += text[1:]
+				diff.Replace += text[1:]
+			case trim == "":
+				diff.Search += text
+				diff.Replace += text
+			default:
+				return Patch{}, &ParseError{Line: lineNo, Text: trim, Msg: "unexpected line in chunk"}
+			}
+		}
+	}
+	return Patch{}, &ParseError{Line: lineNo + 1, Got: EOF, Msg: "expected " + strconv.Quote(v4aEnd)}
+}
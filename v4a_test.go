@@ -0,0 +1,109 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseV4A(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		patch Patch
+		err   bool
+	}{
+		{
+			name:  "empty patch",
+			input: "*** Begin Patch\n*** End Patch\n",
+			patch: Patch{},
+		},
+		{
+			name: "update with multiple chunks",
+			input: "*** Begin Patch\n" +
+				"*** Update File: main.py\n" +
+				"@@ def greet():\n" +
+				" def greet():\n" +
+				"-    print('hi')\n" +
+				"+    print('hello')\n" +
+				"@@\n" +
+				" x = 1\n" +
+				"+y = 2\n" +
+				"*** End Patch\n",
+			patch: Patch{Files: []FileDiff{{
+				Path:    "main.py",
+				OldPath: "main.py",
+				Diffs: []Diff{
+					{Line: 1, Search: "def greet():\n    print('hi')\n", Replace: "def greet():\n    print('hello')\n"},
+					{Line: 3, Search: "x = 1\n", Replace: "x = 1\ny = 2\n"},
+				},
+			}}},
+		},
+		{
+			name: "add, delete, and move",
+			input: "*** Begin Patch\n" +
+				"*** Add File: new.txt\n" +
+				"+hello\n" +
+				"+world\n" +
+				"*** Delete File: old.txt\n" +
+				"*** Update File: a.txt\n" +
+				"*** Move to: b.txt\n" +
+				"@@\n" +
+				"-a\n" +
+				"+b\n" +
+				"*** End of File\n" +
+				"*** End Patch\n",
+			patch: Patch{Files: []FileDiff{
+				{Path: "new.txt", Op: FileCreate, Diffs: []Diff{{Line: 1, Replace: "hello\nworld\n"}}},
+				{OldPath: "old.txt", Op: FileDelete},
+				{Path: "b.txt", OldPath: "a.txt", Op: FileRename, Diffs: []Diff{{Line: EndOfFile, Search: "a\n", Replace: "b\n"}}},
+			}},
+		},
+		{
+			name:  "missing begin",
+			input: "*** Update File: a.txt\n",
+			err:   true,
+		},
+		{
+			name:  "missing end",
+			input: "*** Begin Patch\n*** Update File: a.txt\n-a\n",
+			err:   true,
+		},
+		{
+			name:  "chunk without file",
+			input: "*** Begin Patch\n-a\n*** End Patch\n",
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := ParseV4A(tt.input)
+			if tt.err {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, patch, tt.patch)
+		})
+	}
+}
+
+func TestParseV4AEndOfFile(t *testing.T) {
+	input := "*** Begin Patch\n" +
+		"*** Update File: a.txt\n" +
+		"@@\n" +
+		" x\n" +
+		"+y\n" +
+		"*** End of File\n" +
+		"*** End Patch\n"
+	patch, err := ParseV4A(input)
+	assert.NilError(t, err)
+	diffs := patch.Diffs("a.txt")
+	assert.DeepEqual(t, diffs, []Diff{{Line: EndOfFile, Search: "x\n", Replace: "x\ny\n"}})
+
+	// the chunk applies to the last occurrence of its context
+	m, err := SearchMatch("x\nz\nx\n", diffs[0])
+	assert.NilError(t, err)
+	assert.Equal(t, m.Line, 3)
+}
@@ -0,0 +1,83 @@
+package fuzzypatch
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Severity is the seriousness of a Diagnostic.
+type Severity int
+
+const (
+	SeverityError   Severity = iota // The patch can't be parsed
+	SeverityWarning                 // The patch parses, but probably isn't what was intended
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "unknown"
+	}
+}
+
+// Diagnostic is a problem found by Validate.
+type Diagnostic struct {
+	Line     int      // 1-based line number in the patch
+	Severity Severity // How serious the problem is
+	Message  string   // Description of the problem
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("line %d: %s: %s", d.Line, d.Severity, d.Message)
+}
+
+// Validate checks the syntax of a patch without applying it. The patch can
+// have the file headers accepted by ParsePatch. Syntax errors such as
+// unterminated blocks and bad line hints are reported as errors, and blocks
+// with empty search and replace text or that duplicate an earlier block for
+// the same file are reported as warnings. The diagnostics are ordered by
+// line.
+func Validate(input string, opts ...ParseOption) []Diagnostic {
+	patch, errs := parsePatch(input, append(slices.Clip(opts), WithAllErrors()))
+	var diags []Diagnostic
+	for _, err := range errs {
+		diag := Diagnostic{Severity: SeverityError, Message: err.Error()}
+		var perr *ParseError
+		if errors.As(err, &perr) {
+			diag.Line = perr.Line
+		}
+		diags = append(diags, diag)
+	}
+	type key struct{ path, search, replace string }
+	seen := map[key]Diff{}
+	for _, f := range patch.Files {
+		for _, d := range f.Diffs {
+			if d.Search == "" && d.Replace == "" {
+				diags = append(diags, Diagnostic{
+					Line:     d.Span.Start,
+					Severity: SeverityWarning,
+					Message:  "empty search and replace",
+				})
+				continue
+			}
+			k := key{f.Path, d.Search, d.Replace}
+			if prev, ok := seen[k]; ok {
+				diags = append(diags, Diagnostic{
+					Line:     d.Span.Start,
+					Severity: SeverityWarning,
+					Message:  fmt.Sprintf("duplicate of the block at line %d", prev.Span.Start),
+				})
+				continue
+			}
+			seen[k] = d
+		}
+	}
+	sort.SliceStable(diags, func(i, j int) bool { return diags[i].Line < diags[j].Line })
+	return diags
+}
@@ -0,0 +1,72 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		diags []Diagnostic
+	}{
+		{
+			name:  "valid",
+			input: "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			diags: nil,
+		},
+		{
+			name: "empty block",
+			input: "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+				"<<<<<<< SEARCH line:3\n=======\n>>>>>>> REPLACE\n",
+			diags: []Diagnostic{
+				{Line: 6, Severity: SeverityWarning, Message: "empty search and replace"},
+			},
+		},
+		{
+			name: "duplicate block",
+			input: "<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+				"<<<<<<< SEARCH line:8\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			diags: []Diagnostic{
+				{Line: 6, Severity: SeverityWarning, Message: "duplicate of the block at line 1"},
+			},
+		},
+		{
+			name: "syntax errors",
+			input: "<<<<<<< SEARCH line:x\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+				"<<<<<<< SEARCH line:3\n=======\n>>>>>>> REPLACE\n" +
+				"<<<<<<< SEARCH line:2\nfoo\n=======\nbar\n",
+			diags: []Diagnostic{
+				{Line: 1, Severity: SeverityError, Message: `invalid line hint "x": strconv.Atoi: parsing "x": invalid syntax: "<<<<<<< SEARCH line:x\n" (block 1, line 1)`},
+				{Line: 6, Severity: SeverityWarning, Message: "empty search and replace"},
+				{Line: 13, Severity: SeverityError, Message: `expected EndReplaceType, got EOF: truncated block: "" (block 3, line 13)`},
+			},
+		},
+		{
+			name: "file headers",
+			input: "FILE: a.go\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+				"NEW FILE: b.go\n<<<<<<< SEARCH\n=======\nbaz\n>>>>>>> REPLACE\n" +
+				"DELETE FILE: c.go\n" +
+				"RENAME d.go -> e.go\n",
+			diags: nil,
+		},
+		{
+			name: "duplicate block in another file",
+			input: "FILE: a.go\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+				"FILE: b.go\n<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n" +
+				"<<<<<<< SEARCH line:1\nfoo\n=======\nbar\n>>>>>>> REPLACE\n",
+			diags: []Diagnostic{
+				{Line: 13, Severity: SeverityWarning, Message: "duplicate of the block at line 8"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := Validate(tt.input)
+			assert.DeepEqual(t, diags, tt.diags)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package fuzzypatch
+
+import "gopkg.in/yaml.v3"
+
+// MarshalYAML encodes the patch as a sequence of files, each with the diffs
+// that target it. It uses the same keys as the JSON encoding.
+func (p Patch) MarshalYAML() (any, error) {
+	return patchFiles(p), nil
+}
+
+// UnmarshalYAML decodes the format produced by MarshalYAML.
+func (p *Patch) UnmarshalYAML(value *yaml.Node) error {
+	var entries []patchFile
+	if err := value.Decode(&entries); err != nil {
+		return err
+	}
+	*p = filePatch(entries)
+	return nil
+}
+
+// ParseYAML parses a YAML patch document. See Patch.MarshalYAML for the format.
+func ParseYAML(data []byte) (Patch, error) {
+	var patch Patch
+	if err := yaml.Unmarshal(data, &patch); err != nil {
+		return Patch{}, err
+	}
+	return patch, nil
+}
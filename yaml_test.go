@@ -0,0 +1,68 @@
+package fuzzypatch
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	"gotest.tools/v3/assert"
+)
+
+func TestPatchYAML(t *testing.T) {
+	patch := Patch{Files: []FileDiff{
+		{Path: "a.txt", OldPath: "a.txt", Diffs: []Diff{
+			{Line: 1, Search: "foo\nbar\n", Replace: "baz\n"},
+		}},
+		{Path: "b.txt", OldPath: "b.txt", Diffs: []Diff{
+			{Search: "qux\n", Replace: ""},
+		}},
+	}}
+	data, err := yaml.Marshal(patch)
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), ""+
+		"- path: a.txt\n"+
+		"  diffs:\n"+
+		"    - line: 1\n"+
+		"      search: |\n"+
+		"        foo\n"+
+		"        bar\n"+
+		"      replace: |\n"+
+		"        baz\n"+
+		"- path: b.txt\n"+
+		"  diffs:\n"+
+		"    - search: |\n"+
+		"        qux\n"+
+		"      replace: \"\"\n")
+
+	parsed, err := ParseYAML(data)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, parsed, patch)
+}
+
+func TestPatchYAMLFileOps(t *testing.T) {
+	patch := Patch{Files: []FileDiff{
+		{Path: "new.txt", Op: FileCreate},
+		{OldPath: "old.txt", Op: FileDelete},
+		{Path: "b.txt", OldPath: "a.txt", OldMode: "100644", NewMode: "100755", Op: FileRename},
+	}}
+	data, err := yaml.Marshal(patch)
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), ""+
+		"- path: new.txt\n"+
+		"  op: create\n"+
+		"- old_path: old.txt\n"+
+		"  op: delete\n"+
+		"- path: b.txt\n"+
+		"  old_path: a.txt\n"+
+		"  old_mode: \"100644\"\n"+
+		"  new_mode: \"100755\"\n"+
+		"  op: rename\n")
+
+	parsed, err := ParseYAML(data)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, parsed, patch)
+}
+
+func TestParseYAML(t *testing.T) {
+	_, err := ParseYAML([]byte("path: a.txt\n"))
+	assert.Assert(t, err != nil)
+}